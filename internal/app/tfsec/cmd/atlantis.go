@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/scanners/options"
+	scanner "github.com/aquasecurity/defsec/pkg/scanners/terraform"
+	"github.com/aquasecurity/tfsec/internal/pkg/atlantis"
+)
+
+// scanAtlantisProjects scans the directory of each project in the atlantis configuration as a root module,
+// using the workspace of the project if one is set. The description of every result from a named project
+// is suffixed with the project name, and the metrics of all scans are added together.
+func scanAtlantisProjects(ctx context.Context, scannerOptions []options.ScannerOption, target fs.FS, dir string,
+	config *atlantis.Config) (scan.Results, scanner.Metrics, error) {
+	var results scan.Results
+	var metrics scanner.Metrics
+	for _, project := range config.Projects {
+		projectOptions := append([]options.ScannerOption{}, scannerOptions...)
+		if project.Workspace != "" {
+			projectOptions = append(projectOptions, scanner.ScannerWithWorkspaceName(project.Workspace))
+		}
+		projectResults, projectMetrics, err := scanner.New(projectOptions...).ScanFSWithMetrics(ctx, target, path.Join(dir, project.Dir))
+		if err != nil {
+			return nil, metrics, fmt.Errorf("atlantis project '%s': %w", project.Dir, err)
+		}
+		if project.Name != "" {
+			for i, result := range projectResults {
+				projectResults[i].OverrideDescription(fmt.Sprintf("%s (project: %s)", result.Description(), project.Name))
+			}
+		}
+		addMetrics(&metrics, projectMetrics)
+		results = append(results, projectResults...)
+	}
+	return results, metrics, nil
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/scanners/options"
+	scanner "github.com/aquasecurity/defsec/pkg/scanners/terraform"
+)
+
+// baseline holds the failed results of a scan of an earlier version of the project, such as a checkout of
+// the target branch of a pull request, so that only results introduced since can be reported.
+type baseline struct {
+	remaining map[string]int
+	added     int
+	unchanged int
+}
+
+// loadBaseline scans dir with the given options and records a key for each failed result. The address of each
+// result is mapped through the moved blocks of the current version, so that moved objects are still matched.
+func loadBaseline(ctx context.Context, scannerOptions []options.ScannerOption, target fs.FS, dir string, moves []move) (*baseline, error) {
+	results, err := scanner.New(scannerOptions...).ScanFS(ctx, target, dir)
+	if err != nil {
+		return nil, err
+	}
+	b := &baseline{remaining: make(map[string]int)}
+	for _, result := range results {
+		if result.Status() == scan.StatusFailed {
+			b.remaining[baselineKey(result, moves)]++
+		}
+	}
+	return b, nil
+}
+
+// filter ignores failed results which are also in the baseline. Results are matched by rule and by the
+// full address of the block which caused them, rather than by location, as lines move between versions.
+func (b *baseline) filter(results scan.Results) scan.Results {
+	for i, result := range results {
+		if result.Status() != scan.StatusFailed {
+			continue
+		}
+		if key := baselineKey(result, nil); b.remaining[key] > 0 {
+			b.remaining[key]--
+			b.unchanged++
+			results[i].OverrideStatus(scan.StatusIgnored)
+			continue
+		}
+		b.added++
+	}
+	return results
+}
+
+// removed returns the number of baseline results which were not found again.
+func (b *baseline) removed() int {
+	var removed int
+	for _, count := range b.remaining {
+		removed += count
+	}
+	return removed
+}
+
+func (b *baseline) summary() string {
+	return fmt.Sprintf("Compared with the baseline: %d new, %d unchanged and %d fixed result(s)", b.added, b.unchanged, b.removed())
+}
+
+func baselineKey(result scan.Result, moves []move) string {
+	modules, block := moduleCalls(result)
+	if block != "" {
+		modules = append(modules, block)
+	}
+	return fmt.Sprintf("%s|%s", result.Rule().LongID(), applyMoves(strings.Join(modules, "."), moves))
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/aquasecurity/defsec/pkg/providers"
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/severity"
+	"github.com/aquasecurity/defsec/pkg/terraform"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var cidrOverlapRule = scan.Rule{
+	ShortCode:   "overlapping-cidr-blocks",
+	Summary:     "Subnets in the same module have overlapping CIDR blocks",
+	Explanation: "The CIDR block of the subnet overlaps the CIDR block of another subnet in the same module. This is usually a mistake in the allocation of address ranges, such as a changed prefix length or a hard coded range next to computed ones.",
+	Impact:      "The subnets cannot be created in the same network, or route traffic to the wrong hosts",
+	Resolution:  "Allocate address ranges which do not overlap, such as with cidrsubnets",
+	Provider:    providers.GeneralProvider,
+	Service:     "network",
+	Links:       []string{"https://developer.hashicorp.com/terraform/language/functions/cidrsubnets"},
+	Severity:    severity.Medium,
+}
+
+// subnetCIDRAttributes are the attributes holding the CIDR blocks of each kind of subnet.
+var subnetCIDRAttributes = map[string]string{
+	"aws_subnet":                "cidr_block",
+	"azurerm_subnet":            "address_prefixes",
+	"google_compute_subnetwork": "ip_cidr_range",
+}
+
+type subnetCIDR struct {
+	block   *terraform.Block
+	network *net.IPNet
+}
+
+// findCIDROverlaps compares the evaluated CIDR blocks of the subnets of each kind within each module, and
+// returns a failed result for each subnet with a CIDR block which overlaps one of a subnet declared before
+// it. Subnets in different modules are not compared, as they are often in different networks. Unknown values
+// and values which are not CIDR blocks are left out.
+func findCIDROverlaps(roots []terraform.Modules) scan.Results {
+	var results scan.Results
+	for _, modules := range roots {
+		for _, module := range modules {
+			for resourceType, name := range subnetCIDRAttributes {
+				blocks := module.GetResourcesByType(resourceType)
+				sort.SliceStable(blocks, func(i, j int) bool {
+					return declaredBefore(blocks[i], blocks[j])
+				})
+				var seen []subnetCIDR
+				for _, block := range blocks {
+					attribute := block.GetAttribute(name)
+					if attribute.IsNil() {
+						continue
+					}
+					for _, value := range cidrValues(attribute.Value()) {
+						_, network, err := net.ParseCIDR(value)
+						if err != nil {
+							continue
+						}
+						for _, other := range seen {
+							if other.block == block {
+								continue
+							}
+							if network.Contains(other.network.IP) || other.network.Contains(network.IP) {
+								results.Add(fmt.Sprintf("Subnet '%s' has the CIDR block %s, which overlaps %s of subnet '%s'.", block.FullName(), network, other.network, other.block.FullName()), attribute)
+								break
+							}
+						}
+						seen = append(seen, subnetCIDR{block: block, network: network})
+					}
+				}
+			}
+		}
+	}
+	return results
+}
+
+// declaredBefore checks whether block a is declared before block b, by file and then by line.
+func declaredBefore(a, b *terraform.Block) bool {
+	ra, rb := a.GetMetadata().Range(), b.GetMetadata().Range()
+	if ra.GetFilename() != rb.GetFilename() {
+		return ra.GetFilename() < rb.GetFilename()
+	}
+	return ra.GetStartLine() < rb.GetStartLine()
+}
+
+func cidrValues(value cty.Value) []string {
+	if !value.IsKnown() || value.IsNull() {
+		return nil
+	}
+	if value.Type() == cty.String {
+		return []string{value.AsString()}
+	}
+	if !value.CanIterateElements() {
+		return nil
+	}
+	var values []string
+	for it := value.ElementIterator(); it.Next(); {
+		_, element := it.Element()
+		if element.IsKnown() && !element.IsNull() && element.Type() == cty.String {
+			values = append(values, element.AsString())
+		}
+	}
+	return values
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/terraform"
+)
+
+// warnComputedSources writes a warning for each of the module calls with a source built from locals or
+// variables, and returns the number of warnings written.
+func warnComputedSources(w io.Writer, calls []*terraform.Block, dir string) int {
+	for _, call := range calls {
+		_, _ = fmt.Fprintf(w, "WARNING: %s The source of module '%s' is built from locals or variables, which terraform does not accept, so the module is not scanned. Use --lenient-module-sources to scan it.\n", callLocation(call, dir), call.FullName())
+	}
+	return len(calls)
+}
+
+// findComputedSources returns the module calls below dir with a source which refers to locals, variables or
+// any other value, in file order. Terraform only accepts a literal source, but the scanner evaluates the source
+// like any other attribute, so these modules are loaded when the source resolves to a string.
+func findComputedSources(target fs.FS, dir string) ([]*terraform.Block, error) {
+	var calls []*terraform.Block
+	err := walkTerraformFiles(target, dir, func(filePath string) error {
+		file, _, err := parseTerraformFile(target, filePath)
+		if err != nil {
+			return err
+		}
+		if file == nil {
+			return nil
+		}
+		content, _, _ := file.Body.PartialContent(moduleSourceSchema)
+		for _, block := range content.Blocks {
+			if block.Type != "module" {
+				continue
+			}
+			attributes, _ := block.Body.JustAttributes()
+			if attribute, ok := attributes["source"]; ok && len(attribute.Expr.Variables()) > 0 {
+				calls = append(calls, terraform.NewBlock(block, nil, nil, nil, "", target))
+			}
+		}
+		return nil
+	})
+	return calls, err
+}
+
+// excludeModuleCalls removes the results found in the modules loaded by the given module calls, such as those
+// with a computed source, as the blocks of a loaded module have the module call as their parent.
+func excludeModuleCalls(calls []*terraform.Block) func(scan.Results) scan.Results {
+	excluded := make(map[string]bool)
+	for _, call := range calls {
+		excluded[call.GetMetadata().Range().String()] = true
+	}
+	return func(results scan.Results) scan.Results {
+		var kept scan.Results
+		for _, result := range results {
+			if !inModuleCall(result, excluded) {
+				kept = append(kept, result)
+			}
+		}
+		return kept
+	}
+}
+
+func inModuleCall(result scan.Result, calls map[string]bool) bool {
+	for parent := result.Metadata().Parent(); parent != nil; parent = parent.Parent() {
+		if calls[parent.Range().String()] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/scanners/options"
+	scanner "github.com/aquasecurity/defsec/pkg/scanners/terraform"
+	"github.com/aquasecurity/defsec/pkg/scanners/terraform/parser"
+	"github.com/aquasecurity/defsec/pkg/terraform"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// metaArguments are handled by terraform itself rather than the provider, so they are left out of the values.
+var metaArguments = map[string]bool{
+	"count":       true,
+	"for_each":    true,
+	"depends_on":  true,
+	"provider":    true,
+	"lifecycle":   true,
+	"provisioner": true,
+	"connection":  true,
+	"dynamic":     true,
+}
+
+type evaluatedConfig struct {
+	FormatVersion string                `json:"format_version"`
+	Root          string                `json:"root"`
+	PlannedValues evaluatedPlannedValue `json:"planned_values"`
+}
+
+type evaluatedPlannedValue struct {
+	RootModule *evaluatedModule `json:"root_module"`
+}
+
+type evaluatedModule struct {
+	Address      string              `json:"address,omitempty"`
+	Resources    []evaluatedResource `json:"resources"`
+	ChildModules []*evaluatedModule  `json:"child_modules,omitempty"`
+	children     map[string]*evaluatedModule
+}
+
+type evaluatedResource struct {
+	Address       string                 `json:"address"`
+	Mode          string                 `json:"mode"`
+	Type          string                 `json:"type"`
+	Name          string                 `json:"name"`
+	Index         interface{}            `json:"index,omitempty"`
+	Values        map[string]interface{} `json:"values"`
+	UnknownValues map[string]interface{} `json:"unknown_values,omitempty"`
+	Range         evaluatedRange         `json:"range"`
+}
+
+type evaluatedRange struct {
+	Filename  string `json:"filename"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+}
+
+// parserOptionsCollector records the parser options set by scanner options, so that the configuration is
+// parsed with the same tfvars, workspace and download settings as a scan would use.
+type parserOptionsCollector struct {
+	*scanner.Scanner
+	options []options.ParserOption
+}
+
+func (c *parserOptionsCollector) AddParserOptions(opts ...options.ParserOption) {
+	c.options = append(c.options, opts...)
+}
+
+func (c *parserOptionsCollector) SetDebugWriter(writer io.Writer) {
+	c.options = append(c.options, options.ParserWithDebug(writer))
+}
+
+func (c *parserOptionsCollector) SetSkipRequiredCheck(skip bool) {
+	c.options = append(c.options, options.ParserWithSkipRequiredCheck(skip))
+}
+
+// writeEvaluatedConfig evaluates each root module below dir, as found by the scanner, and writes the resources and
+// data sources of every module as a JSON document for each root, laid out like the planned values of a terraform
+// plan. Unknown values are written as null, and marked as true at the same position in unknown_values, as
+// terraform does for after_unknown.
+func writeEvaluatedConfig(w io.Writer, scannerOptions []options.ScannerOption, target fs.FS, dir string) error {
+	collector := &parserOptionsCollector{Scanner: scanner.New()}
+	for _, option := range scannerOptions {
+		option(collector)
+	}
+	roots := findRootModules(target, dir)
+	if len(roots) == 0 {
+		roots = []string{dir}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	for _, rootDir := range roots {
+		p := parser.New(target, "", collector.options...)
+		if err := p.ParseFS(context.TODO(), rootDir); err != nil {
+			return err
+		}
+		modules, _, err := p.EvaluateAll(context.TODO())
+		if err != nil {
+			return err
+		}
+
+		root := &evaluatedModule{children: make(map[string]*evaluatedModule)}
+		for _, module := range modules {
+			for _, block := range module.GetBlocks() {
+				if block.Type() != "resource" && block.Type() != "data" {
+					continue
+				}
+				address := block.FullName()
+				parent := root.child(strings.TrimSuffix(strings.TrimSuffix(address, block.LocalName()), "."))
+				parent.Resources = append(parent.Resources, evaluateResource(block, address, dir))
+			}
+		}
+		root.sort()
+
+		rel, err := filepath.Rel(dir, rootDir)
+		if err != nil {
+			return err
+		}
+		if err := encoder.Encode(evaluatedConfig{
+			FormatVersion: "1.0",
+			Root:          filepath.ToSlash(rel),
+			PlannedValues: evaluatedPlannedValue{RootModule: root},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// child returns the module with the given address, creating it and any modules it is nested in.
+func (m *evaluatedModule) child(address string) *evaluatedModule {
+	if address == "" {
+		return m
+	}
+	parent := m
+	if i := strings.LastIndex(address, ".module."); i >= 0 {
+		parent = m.child(address[:i])
+	}
+	if existing, ok := parent.children[address]; ok {
+		return existing
+	}
+	created := &evaluatedModule{Address: address, children: make(map[string]*evaluatedModule)}
+	parent.children[address] = created
+	parent.ChildModules = append(parent.ChildModules, created)
+	return created
+}
+
+func (m *evaluatedModule) sort() {
+	if m.Resources == nil {
+		m.Resources = []evaluatedResource{}
+	}
+	sort.Slice(m.ChildModules, func(i, j int) bool {
+		return m.ChildModules[i].Address < m.ChildModules[j].Address
+	})
+	for _, child := range m.ChildModules {
+		child.sort()
+	}
+}
+
+func evaluateResource(block *terraform.Block, address, dir string) evaluatedResource {
+	mode := "managed"
+	if block.Type() == "data" {
+		mode = "data"
+	}
+	values, unknown := evaluateBlock(block)
+	resource := evaluatedResource{
+		Address:       address,
+		Mode:          mode,
+		Type:          block.TypeLabel(),
+		Name:          strings.Split(block.NameLabel(), "[")[0],
+		Values:        values,
+		UnknownValues: unknown,
+	}
+	if ref, ok := block.GetMetadata().Reference().(*terraform.Reference); ok {
+		if key := ref.RawKey(); !key.IsNull() {
+			resource.Index, _ = evaluateValue(key)
+		}
+	}
+	rng := block.GetMetadata().Range()
+	resource.Range = evaluatedRange{
+		Filename:  rng.GetFilename(),
+		StartLine: rng.GetStartLine(),
+		EndLine:   rng.GetEndLine(),
+	}
+	if rng.GetSourcePrefix() == "" {
+		if rel, err := filepath.Rel(dir, rng.GetLocalFilename()); err == nil {
+			resource.Range.Filename = filepath.ToSlash(rel)
+		}
+	}
+	return resource
+}
+
+// evaluateBlock returns the values of the attributes and nested blocks of the block, with nested blocks
+// given as a list of objects under their type, along with the positions of any unknown values.
+func evaluateBlock(block *terraform.Block) (map[string]interface{}, map[string]interface{}) {
+	values := make(map[string]interface{})
+	unknown := make(map[string]interface{})
+	for _, attribute := range block.GetAttributes() {
+		if metaArguments[attribute.Name()] {
+			continue
+		}
+		value, unknownValue := evaluateValue(attribute.Value())
+		values[attribute.Name()] = value
+		if unknownValue != nil {
+			unknown[attribute.Name()] = unknownValue
+		}
+	}
+	nestedUnknown := make(map[string][]interface{})
+	for _, nested := range block.AllBlocks() {
+		if metaArguments[nested.Type()] {
+			continue
+		}
+		nestedValues, nestedUnknownValues := evaluateBlock(nested)
+		list, _ := values[nested.Type()].([]interface{})
+		values[nested.Type()] = append(list, nestedValues)
+		var mask interface{} = false
+		if nestedUnknownValues != nil {
+			mask = nestedUnknownValues
+		}
+		nestedUnknown[nested.Type()] = append(nestedUnknown[nested.Type()], mask)
+	}
+	for name, masks := range nestedUnknown {
+		for _, mask := range masks {
+			if mask != false {
+				unknown[name] = masks
+				break
+			}
+		}
+	}
+	if len(unknown) == 0 {
+		unknown = nil
+	}
+	return values, unknown
+}
+
+// evaluateValue converts the value for encoding as JSON. The second value returned is nil if the value is
+// fully known, true if it is unknown, or a list or map giving the positions of the unknown values within it.
+func evaluateValue(val cty.Value) (interface{}, interface{}) {
+	val, _ = val.UnmarkDeep()
+	if !val.IsKnown() {
+		return nil, true
+	}
+	if val.IsNull() {
+		return nil, nil
+	}
+	ty := val.Type()
+	switch {
+	case ty == cty.String:
+		return val.AsString(), nil
+	case ty == cty.Number:
+		return json.Number(val.AsBigFloat().Text('f', -1)), nil
+	case ty == cty.Bool:
+		return val.True(), nil
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		values := []interface{}{}
+		var masks []interface{}
+		var hasUnknown bool
+		for it := val.ElementIterator(); it.Next(); {
+			_, element := it.Element()
+			value, unknownValue := evaluateValue(element)
+			values = append(values, value)
+			if unknownValue != nil {
+				hasUnknown = true
+				masks = append(masks, unknownValue)
+			} else {
+				masks = append(masks, false)
+			}
+		}
+		if !hasUnknown {
+			return values, nil
+		}
+		return values, masks
+	case ty.IsMapType() || ty.IsObjectType():
+		values := make(map[string]interface{})
+		masks := make(map[string]interface{})
+		for it := val.ElementIterator(); it.Next(); {
+			key, element := it.Element()
+			value, unknownValue := evaluateValue(element)
+			values[key.AsString()] = value
+			if unknownValue != nil {
+				masks[key.AsString()] = unknownValue
+			}
+		}
+		if len(masks) == 0 {
+			return values, nil
+		}
+		return values, masks
+	default:
+		return nil, nil
+	}
+}
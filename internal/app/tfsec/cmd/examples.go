@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+	scanner "github.com/aquasecurity/defsec/pkg/scanners/terraform"
+)
+
+// scanExamples scans each directory in the examples directory of the module at dir as a root module of
+// its own. The description of every result is suffixed with the name of the example it was found by,
+// and the metrics of each scan are added to the given metrics.
+func scanExamples(ctx context.Context, scnr *scanner.Scanner, target fs.FS, dir string,
+	metrics *scanner.Metrics) (scan.Results, error) {
+	examplesDir := path.Join(dir, "examples")
+	entries, err := fs.ReadDir(target, examplesDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var results scan.Results
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		exampleResults, exampleMetrics, err := scnr.ScanFSWithMetrics(ctx, target, path.Join(examplesDir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("example '%s': %w", entry.Name(), err)
+		}
+		for i, result := range exampleResults {
+			exampleResults[i].OverrideDescription(fmt.Sprintf("%s (example: %s)", result.Description(), entry.Name()))
+		}
+		addMetrics(metrics, exampleMetrics)
+		results = append(results, exampleResults...)
+	}
+	return results, nil
+}
+
+func addMetrics(metrics *scanner.Metrics, other scanner.Metrics) {
+	metrics.Parser.Counts.Blocks += other.Parser.Counts.Blocks
+	metrics.Parser.Counts.Modules += other.Parser.Counts.Modules
+	metrics.Parser.Counts.Files += other.Parser.Counts.Files
+	metrics.Parser.Counts.ModuleDownloads = other.Parser.Counts.ModuleDownloads
+	metrics.Parser.Timings.DiskIODuration += other.Parser.Timings.DiskIODuration
+	metrics.Parser.Timings.ParseDuration += other.Parser.Timings.ParseDuration
+	metrics.Executor.Counts.Passed += other.Executor.Counts.Passed
+	metrics.Executor.Counts.Failed += other.Executor.Counts.Failed
+	metrics.Executor.Counts.Ignored += other.Executor.Counts.Ignored
+	metrics.Executor.Counts.Critical += other.Executor.Counts.Critical
+	metrics.Executor.Counts.High += other.Executor.Counts.High
+	metrics.Executor.Counts.Medium += other.Executor.Counts.Medium
+	metrics.Executor.Counts.Low += other.Executor.Counts.Low
+	metrics.Executor.Timings.Adaptation += other.Executor.Timings.Adaptation
+	metrics.Executor.Timings.RunningChecks += other.Executor.Timings.RunningChecks
+	metrics.Timings.Total += other.Timings.Total
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+var terraformBlockSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{{Type: "terraform"}},
+}
+
+var experimentsSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "experiments"}},
+}
+
+// experimentEffects describes how results differ for the experiments which change evaluation, as the
+// evaluator always follows the behaviour of the stable language.
+var experimentEffects = map[string]string{
+	"module_variable_optional_attrs": "Type constraints are not applied to variables, so optional object attributes are not added as null when omitted, and values which refer to them may not be evaluated",
+}
+
+type experiment struct {
+	Filename string
+	Line     int
+	Name     string
+}
+
+// warnExperiments writes a warning for each Terraform experiment a module below dir opts into, as experiments
+// are not supported and the results for the module may not match how Terraform evaluates it.
+func warnExperiments(w io.Writer, target fs.FS, dir string) error {
+	experiments, err := findExperiments(target, dir)
+	if err != nil {
+		return fmt.Errorf("failed to find experiments: %w", err)
+	}
+	for _, experiment := range experiments {
+		_, _ = fmt.Fprintf(w, "WARNING: %s:%d opts into the Terraform experiment %s, which is not supported. Results for this module may differ from Terraform.", experiment.Filename, experiment.Line, experiment.Name)
+		if effect, ok := experimentEffects[experiment.Name]; ok {
+			_, _ = fmt.Fprintf(w, " %s.", effect)
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// findExperiments returns the experiments listed in the terraform blocks of the modules below dir.
+func findExperiments(target fs.FS, dir string) ([]experiment, error) {
+	var experiments []experiment
+	err := walkTerraformFiles(target, dir, func(filePath string) error {
+		found, err := findFileExperiments(target, filePath)
+		if err != nil {
+			return err
+		}
+		experiments = append(experiments, found...)
+		return nil
+	})
+	return experiments, err
+}
+
+func findFileExperiments(target fs.FS, filePath string) ([]experiment, error) {
+	file, _, err := parseTerraformFile(target, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, nil
+	}
+
+	content, _, _ := file.Body.PartialContent(terraformBlockSchema)
+	var experiments []experiment
+	for _, block := range content.Blocks {
+		blockContent, _, _ := block.Body.PartialContent(experimentsSchema)
+		attribute, ok := blockContent.Attributes["experiments"]
+		if !ok {
+			continue
+		}
+		// experiments are given as bare keywords, or as strings in JSON configuration
+		exprs, diags := hcl.ExprList(attribute.Expr)
+		if diags.HasErrors() {
+			continue
+		}
+		for _, expr := range exprs {
+			traversal, diags := hcl.AbsTraversalForExpr(expr)
+			if diags.HasErrors() || len(traversal) != 1 {
+				continue
+			}
+			experiments = append(experiments, experiment{
+				Filename: filePath,
+				Line:     expr.Range().Start.Line,
+				Name:     traversal.RootName(),
+			})
+		}
+	}
+	return experiments, nil
+}
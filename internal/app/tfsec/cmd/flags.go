@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/aquasecurity/defsec/pkg/scanners/options"
@@ -34,7 +35,12 @@ var softFail bool
 var filterResults string
 var excludedRuleIDs string
 var tfvarsPaths []string
+var varFileStdinFormat string
 var excludePaths []string
+var modulePrefixes []string
+var resourceTypes []string
+var blockTypes []string
+var moduleOverrides []string
 var outputFlag string
 var customCheckDir string
 var customCheckUrl string
@@ -46,8 +52,21 @@ var includePassed bool
 var includeIgnored bool
 var allDirs bool
 var migrateIgnores bool
+var listIgnores bool
+var listUnusedModules bool
+var listUnusedVariables bool
+var listProviderConstraints bool
+var warnShorthandSources bool
+var lenientModuleSources bool
+var strictModuleWarnings bool
+var moduleSourceErrors string
+var validateModuleCalls bool
+var checkCIDROverlaps bool
+var ignoreExpiryWarningDays int
+var fixedTimestamp string
 var runStatistics bool
 var ignoreHCLErrors bool
+var hclErrorsAsResults bool
 var stopOnCheckError bool
 var workspace string
 var singleThreadedMode bool
@@ -57,28 +76,56 @@ var minimumSeverity string
 var disableIgnores bool
 var regoPolicyDir string
 var printRegoInput bool
+var printEvaluatedConfig bool
 var noModuleDownloads bool
+var noGitLFS bool
 var regoOnly bool
 var codeTheme string
 var noCode bool
+var mergeInstances bool
+var showModuleChain bool
+var includeExamples bool
+var atlantisProjects bool
+var baselineDir string
+var flattenModules bool
 
 func configureFlags(cmd *cobra.Command) {
 
 	cmd.Flags().BoolVar(&singleThreadedMode, "single-thread", false, "Run checks using a single thread")
 	cmd.Flags().BoolVarP(&disableGrouping, "disable-grouping", "G", false, "Disable grouping of similar results")
 	cmd.Flags().BoolVar(&ignoreHCLErrors, "ignore-hcl-errors", false, "Do not report an error if an HCL parse error is encountered")
+	cmd.Flags().BoolVar(&hclErrorsAsResults, "hcl-errors-as-results", false, "Report HCL parse errors and modules which could not be loaded as results, with the IDs general-terraform-parse-error and general-terraform-module-load-error, instead of stopping the scan")
 	cmd.Flags().BoolVar(&disableColours, "no-colour", false, "Disable coloured output")
 	cmd.Flags().BoolVar(&disableColours, "no-color", false, "Disable colored output (American style!)")
 	cmd.Flags().BoolVarP(&showVersion, "version", "v", false, "Show version information and exit")
 	cmd.Flags().BoolVar(&runUpdate, "update", false, "Update to latest version")
 	cmd.Flags().BoolVar(&migrateIgnores, "migrate-ignores", false, "Migrate ignore codes to the new ID structure")
+	cmd.Flags().BoolVar(&listIgnores, "list-ignores", false, "List the ignore comments found in terraform files, with their expiry dates and workspaces, and exit. Use --format json for machine readable output")
+	cmd.Flags().BoolVar(&listUnusedModules, "list-unused-modules", false, "List the directories of terraform files which are not used by any module block, such as stale local modules, and exit")
+	cmd.Flags().BoolVar(&listUnusedVariables, "list-unused-variables", false, "List the variables declared in each module which are not referenced anywhere else in the module, and exit")
+	cmd.Flags().BoolVar(&listProviderConstraints, "list-provider-constraints", false, "List the providers required by each module with their sources and version constraints, marking those which are unpinned, and exit. Use --format json for machine readable output")
+	cmd.Flags().BoolVar(&warnShorthandSources, "warn-module-shorthand", false, "Warn about module sources which use the github.com or bitbucket.org shorthand, recommending an explicit git:: source with a pinned ref")
+	cmd.Flags().BoolVar(&lenientModuleSources, "lenient-module-sources", false, "Load modules with a source built from locals or variables, which terraform does not accept, if the source resolves to a string")
+	cmd.Flags().BoolVar(&strictModuleWarnings, "strict-module-warnings", false, "Warn about module calls which could not be loaded, and fail the scan after writing the results if there were any module warnings, including those from --warn-module-shorthand, even with --soft-fail")
+	cmd.Flags().StringVar(&moduleSourceErrors, "module-source-errors", "skip", "How to handle module blocks without a source which can be evaluated to a string: skip leaves the module out, finding reports a result with the ID general-terraform-module-load-error, and error stops the scan")
+	cmd.Flags().BoolVar(&validateModuleCalls, "validate-module-calls", false, "Report module blocks which set arguments the module does not declare, or do not set its required variables, as results with the ID general-terraform-module-call-arguments")
+	cmd.Flags().BoolVar(&checkCIDROverlaps, "check-cidr-overlaps", false, "Report subnets whose evaluated CIDR blocks overlap those of another subnet of the same kind in the same module, as results with the ID general-network-overlapping-cidr-blocks")
+	cmd.Flags().IntVar(&ignoreExpiryWarningDays, "ignore-expiry-warning", 0, "Warn about ignore comments which expire within the given number of days, and report the ignore comments which have expired as results with the ID general-terraform-expired-ignore")
+	cmd.Flags().StringVar(&fixedTimestamp, "timestamp", "", "Use the given RFC 3339 time, such as 2022-03-01T12:00:00Z, as the result of timestamp() instead of the current time, so that scans are reproducible")
 	cmd.Flags().StringVarP(&format, "format", "f", "lovely", "Select output format: lovely, json, csv, checkstyle, junit, sarif, text, markdown, html, gif. To use multiple formats, separate with a comma and specify a base output filename with --out. A file will be written for each type. The first format will additionally be written stdout.")
 	cmd.Flags().StringVarP(&excludedRuleIDs, "exclude", "e", "", "Provide comma-separated list of rule IDs to exclude from run.")
 	cmd.Flags().StringVar(&filterResults, "filter-results", "", "Filter results to return specific checks only (supports comma-delimited input).")
 	cmd.Flags().BoolVarP(&softFail, "soft-fail", "s", false, "Runs checks but suppresses error code")
-	cmd.Flags().StringSliceVar(&tfvarsPaths, "tfvars-file", nil, "Path to .tfvars file, can be used multiple times and evaluated in order of specification")
-	cmd.Flags().StringSliceVar(&tfvarsPaths, "var-file", nil, "Path to .tfvars file, can be used multiple times and evaluated in order of specification (same functionaility as --tfvars-file but consistent with Terraform)")
+	cmd.Flags().StringSliceVar(&tfvarsPaths, "tfvars-file", nil, "Path to .tfvars file, can be used multiple times and evaluated in order of specification. Glob patterns are expanded in lexical order")
+	cmd.Flags().StringSliceVar(&tfvarsPaths, "var-file", nil, "Path to .tfvars file, can be used multiple times and evaluated in order of specification. Glob patterns are expanded in lexical order, and - reads from stdin (same functionaility as --tfvars-file but consistent with Terraform)")
+	cmd.Flags().StringVar(&varFileStdinFormat, "var-file-stdin-format", "", "Format of the tfvars read from stdin with --var-file=-, either 'hcl' or 'json'. Detected from the content if not set.")
 	cmd.Flags().StringSliceVar(&excludePaths, "exclude-path", nil, "Folder path to exclude, can be used multiple times and evaluated in order of specification")
+	cmd.Flags().BoolVar(&includeExamples, "scan-examples", false, "Also scan each directory in the examples directory as a root module, to check the module as it is used by its examples")
+	cmd.Flags().StringVar(&baselineDir, "baseline-dir", "", "Scan the directory as a baseline, such as a checkout of the target branch, and ignore results which are also found in it so that only new results are reported")
+	cmd.Flags().BoolVar(&atlantisProjects, "atlantis-projects", false, "Scan the project directories listed in the atlantis.yaml of the target directory, each with its configured workspace, instead of finding root modules")
+	cmd.Flags().StringSliceVar(&modulePrefixes, "module-prefix", nil, "Only show results found within the module address, e.g. module.network, including any nested modules. Can be used multiple times")
+	cmd.Flags().StringSliceVar(&blockTypes, "block-types", nil, "Only show results caused by blocks of the given types, e.g. resource,data. Every block is still loaded, evaluated and checked, so this filters the results but does not make the scan faster")
+	cmd.Flags().StringSliceVar(&resourceTypes, "resource-types", nil, "Only show results caused by resources of the given types, e.g. aws_s3_bucket,aws_security_group. Every block is still loaded, evaluated and checked, so this filters the results but does not make the scan faster")
 	cmd.Flags().StringVarP(&outputFlag, "out", "O", "", "Set output file. This filename will have a format descriptor appended if multiple formats are specified with --format")
 	cmd.Flags().StringVar(&customCheckDir, "custom-check-dir", "", "Explicitly set the custom checks dir location")
 	cmd.Flags().StringVar(&customCheckUrl, "custom-check-url", "",
@@ -95,14 +142,20 @@ func configureFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&allDirs, "force-all-dirs", false, "Don't search for tf files, include everything below provided directory.")
 	cmd.Flags().BoolVar(&runStatistics, "run-statistics", false, "View statistics table of current findings.")
 	cmd.Flags().BoolVarP(&stopOnCheckError, "allow-checks-to-panic", "p", false, "Allow panics to propagate up from rule checking")
-	cmd.Flags().StringVarP(&workspace, "workspace", "w", "default", "Specify a workspace for ignore limits")
+	cmd.Flags().StringVarP(&workspace, "workspace", "w", "default", "Specify a workspace for ignore limits and the value of terraform.workspace during evaluation")
 	cmd.Flags().StringVarP(&minimumSeverity, "minimum-severity", "m", "", "The minimum severity to report. One of CRITICAL, HIGH, MEDIUM, LOW.")
 	cmd.Flags().StringVar(&regoPolicyDir, "rego-policy-dir", "", "Directory to load rego policies from (recursively).")
 	cmd.Flags().BoolVar(&printRegoInput, "print-rego-input", false, "Print a JSON representation of the input supplied to rego policies.")
+	cmd.Flags().BoolVar(&printEvaluatedConfig, "print-evaluated-config", false, "Print the evaluated resources and data sources of every module as JSON, laid out like the planned values of a terraform plan, instead of scanning. A document is written for each root module, with its directory in the root field.")
 	cmd.Flags().BoolVar(&noModuleDownloads, "no-module-downloads", false, "Do not download remote modules.")
+	cmd.Flags().StringSliceVar(&moduleOverrides, "module-override", nil, "Load the module with the given key from a local directory instead of its source, e.g. network.subnets=../subnets. For a source with a subdirectory, such as repo//modules/x, the directory may be the module or a checkout of the repository. Can be used multiple times")
+	cmd.Flags().BoolVar(&noGitLFS, "no-git-lfs", false, "Do not fetch Git LFS files after cloning a module repository which uses Git LFS.")
 	cmd.Flags().BoolVar(&regoOnly, "rego-only", false, "Run rego policies exclusively.")
 	cmd.Flags().StringVar(&codeTheme, "code-theme", "dark", "Theme for annotated code. Either 'light' or 'dark'.")
 	cmd.Flags().BoolVar(&noCode, "no-code", false, "Don't include the code snippets in the output.")
+	cmd.Flags().BoolVar(&flattenModules, "flatten-modules", false, "Show the full module address of each result instead of the chain of module calls it was found via.")
+	cmd.Flags().BoolVar(&showModuleChain, "module-chain", false, "Add the chain of module calls leading to the resource to the description of each result found within a module, e.g. module.a → module.b → aws_s3_bucket.x")
+	cmd.Flags().BoolVar(&mergeInstances, "merge-instances", false, "Merge results which differ only by count/for_each instance into a single result listing the affected instance keys.")
 
 	_ = cmd.Flags().MarkHidden("allow-checks-to-panic")
 }
@@ -156,13 +209,13 @@ func excludeFunc(excludePaths []string) func(results scan.Results) scan.Results
 	}
 }
 
-func configureOptions(cmd *cobra.Command, fsRoot, dir string) ([]options.ScannerOption, error) {
+func configureOptions(cmd *cobra.Command, fsRoot, dir string, stdinVars *stdinVars) ([]options.ScannerOption, error) {
 
 	var scannerOptions []options.ScannerOption
 	scannerOptions = append(
 		scannerOptions,
 		scanner.ScannerWithSingleThread(singleThreadedMode),
-		scanner.ScannerWithStopOnHCLError(!ignoreHCLErrors),
+		scanner.ScannerWithStopOnHCLError(!ignoreHCLErrors && !hclErrorsAsResults),
 		scanner.ScannerWithStopOnRuleErrors(stopOnCheckError),
 		scanner.ScannerWithSkipDownloaded(excludeDownloaded),
 		scanner.ScannerWithAllDirectories(allDirs),
@@ -179,11 +232,53 @@ func configureOptions(cmd *cobra.Command, fsRoot, dir string) ([]options.Scanner
 		scannerOptions = append(scannerOptions, scanner.ScannerWithResultsFilter(excludeFunc(excludePaths)))
 	}
 
+	if len(modulePrefixes) > 0 {
+		scannerOptions = append(scannerOptions, scanner.ScannerWithResultsFilter(modulePrefixFunc(modulePrefixes)))
+	}
+
+	if len(blockTypes) > 0 {
+		scannerOptions = append(scannerOptions, scanner.ScannerWithResultsFilter(blockTypesFunc(blockTypes)))
+	}
+
+	if len(resourceTypes) > 0 {
+		scannerOptions = append(scannerOptions, scanner.ScannerWithResultsFilter(resourceTypesFunc(resourceTypes)))
+	}
+
+	if mergeInstances {
+		scannerOptions = append(scannerOptions, scanner.ScannerWithResultsFilter(mergeInstancesFunc()))
+	}
+
+	if showModuleChain {
+		scannerOptions = append(scannerOptions, scanner.ScannerWithResultsFilter(moduleChainFunc()))
+	}
+
+	switch moduleSourceErrors {
+	case "skip", "finding", "error":
+	default:
+		return nil, fmt.Errorf("'%s' is not a valid way to handle module source errors - should be one of skip, finding, error", moduleSourceErrors)
+	}
+
+	if err := useFixedTimestamp(fixedTimestamp); err != nil {
+		return nil, err
+	}
+
 	if len(tfvarsPaths) > 0 {
-		fixedPaths, err := makePathsRelativeToFSRoot(fsRoot, tfvarsPaths)
+		expandedPaths, err := expandTfvarsGlobs(tfvarsPaths)
 		if err != nil {
 			return nil, fmt.Errorf("tfvars problem: %w", err)
 		}
+		var fixedPaths []string
+		for _, path := range expandedPaths {
+			if path == "-" {
+				fixedPaths = append(fixedPaths, stdinVars.name)
+				continue
+			}
+			fixedPath, err := makePathRelativeToFSRoot(fsRoot, path)
+			if err != nil {
+				return nil, fmt.Errorf("tfvars problem: %w", err)
+			}
+			fixedPaths = append(fixedPaths, fixedPath)
+		}
 		scannerOptions = append(scannerOptions, scanner.ScannerWithTFVarsPaths(fixedPaths...))
 	}
 
@@ -227,7 +322,7 @@ func configureOptions(cmd *cobra.Command, fsRoot, dir string) ([]options.Scanner
 		}))
 	}
 
-	return applyConfigFiles(scannerOptions, dir)
+	return applyConfigFiles(cmd, scannerOptions, fsRoot, dir)
 }
 
 func explodeGlob(paths []string, root string, dir string) []string {
@@ -248,7 +343,29 @@ func explodeGlob(paths []string, root string, dir string) []string {
 	return exploded
 }
 
-func applyConfigFiles(options []options.ScannerOption, dir string) ([]options.ScannerOption, error) {
+// expandTfvarsGlobs replaces any glob patterns in the given tfvars paths with the files they match, in
+// lexical order. The position of each pattern in the list is preserved so later files still take precedence.
+func expandTfvarsGlobs(paths []string) ([]string, error) {
+	var expanded []string
+	for _, path := range paths {
+		if !strings.ContainsAny(path, "*?[") {
+			expanded = append(expanded, path)
+			continue
+		}
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern '%s': %w", path, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no tfvars files matched '%s'", path)
+		}
+		sort.Strings(matches)
+		expanded = append(expanded, matches...)
+	}
+	return expanded, nil
+}
+
+func applyConfigFiles(cmd *cobra.Command, options []options.ScannerOption, fsRoot, dir string) ([]options.ScannerOption, error) {
 	if configFileUrl != "" {
 		if remoteConfigDownloaded() {
 			defer func() { _ = os.Remove(configFile) }()
@@ -285,6 +402,11 @@ func applyConfigFiles(options []options.ScannerOption, dir string) ([]options.Sc
 			if len(conf.ExcludedChecks) > 0 {
 				options = append(options, scanner.ScannerWithExcludedRules(append(conf.ExcludedChecks, excludedRuleIDs)))
 			}
+			loaderOptions, err := configureLoaderOptions(cmd, conf, fsRoot, dir)
+			if err != nil {
+				return nil, fmt.Errorf("config file problem: %w", err)
+			}
+			options = append(options, loaderOptions...)
 		} else {
 			logger.Log("Failed to load config file: %s", err)
 		}
@@ -293,6 +415,41 @@ func applyConfigFiles(options []options.ScannerOption, dir string) ([]options.Sc
 	return configureCustomChecks(options, dir)
 }
 
+// configureLoaderOptions applies the module loading settings from the config file. Settings which were also
+// provided as flags are skipped, so the command line always takes precedence over the config file.
+func configureLoaderOptions(cmd *cobra.Command, conf *config.Config, fsRoot, dir string) ([]options.ScannerOption, error) {
+	var scannerOptions []options.ScannerOption
+
+	if len(conf.TfvarsFiles) > 0 && !cmd.Flags().Changed("tfvars-file") && !cmd.Flags().Changed("var-file") {
+		var paths []string
+		for _, path := range conf.TfvarsFiles {
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			paths = append(paths, path)
+		}
+		expandedPaths, err := expandTfvarsGlobs(paths)
+		if err != nil {
+			return nil, fmt.Errorf("tfvars problem: %w", err)
+		}
+		fixedPaths, err := makePathsRelativeToFSRoot(fsRoot, expandedPaths)
+		if err != nil {
+			return nil, fmt.Errorf("tfvars problem: %w", err)
+		}
+		scannerOptions = append(scannerOptions, scanner.ScannerWithTFVarsPaths(fixedPaths...))
+	}
+
+	if len(conf.ExcludePaths) > 0 && !cmd.Flags().Changed("exclude-path") {
+		scannerOptions = append(scannerOptions, scanner.ScannerWithResultsFilter(excludeFunc(explodeGlob(conf.ExcludePaths, fsRoot, dir))))
+	}
+
+	if conf.NoModuleDownloads && !cmd.Flags().Changed("no-module-downloads") {
+		scannerOptions = append(scannerOptions, scanner.ScannerWithDownloadsAllowed(false))
+	}
+
+	return scannerOptions, nil
+}
+
 func configureCustomChecks(options []options.ScannerOption, dir string) ([]options.ScannerOption, error) {
 	if customCheckUrl != "" {
 		if remoteCustomCheckDownloaded() {
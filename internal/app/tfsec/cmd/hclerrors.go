@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+
+	"github.com/aquasecurity/defsec/pkg/providers"
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/severity"
+	"github.com/aquasecurity/defsec/pkg/terraform"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var hclErrorRule = scan.Rule{
+	ShortCode:   "parse-error",
+	Summary:     "Terraform configuration could not be parsed",
+	Explanation: "The file contains HCL which could not be parsed, so none of the configuration in it has been checked.",
+	Impact:      "Misconfigurations in the file are not detected",
+	Resolution:  "Fix the HCL syntax error",
+	Provider:    providers.GeneralProvider,
+	Service:     "terraform",
+	Links:       []string{"https://developer.hashicorp.com/terraform/language/syntax/configuration"},
+	Severity:    severity.High,
+}
+
+var moduleLoadErrorRule = scan.Rule{
+	ShortCode:   "module-load-error",
+	Summary:     "Terraform module could not be loaded",
+	Explanation: "The module could not be found or downloaded from its source, so none of the configuration in it has been checked.",
+	Impact:      "Misconfigurations in the module are not detected",
+	Resolution:  "Fix the module source, or run terraform init to install the module",
+	Provider:    providers.GeneralProvider,
+	Service:     "terraform",
+	Links:       []string{"https://developer.hashicorp.com/terraform/language/modules/sources"},
+	Severity:    severity.High,
+}
+
+// findHCLErrors parses every terraform file below dir and returns a failed result for each error
+// diagnostic, so that syntax errors are reported alongside the results of the scan.
+func findHCLErrors(target fs.FS, dir string) (scan.Results, error) {
+	var results scan.Results
+	err := walkTerraformFiles(target, dir, func(filePath string) error {
+		_, diags, err := parseTerraformFile(target, filePath)
+		if err != nil {
+			return err
+		}
+		for _, diag := range diags {
+			if diag.Severity != hcl.DiagError || diag.Subject == nil {
+				continue
+			}
+			results.Add(fmt.Sprintf("%s: %s", diag.Summary, diag.Detail), sourceBlock(target, *diag.Subject))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// failedModuleCall is a module call in an evaluated root module which did not load. The source is empty if the
+// module block has no source, or the source could not be evaluated to a string.
+type failedModuleCall struct {
+	block  *terraform.Block
+	source string
+}
+
+func (c failedModuleCall) String() string {
+	source := "an unknown source"
+	if c.source != "" {
+		source = fmt.Sprintf("'%s'", c.source)
+	}
+	return fmt.Sprintf("Module '%s' could not be loaded from %s.", c.block.FullName(), source)
+}
+
+// findFailedModuleCalls returns each module call in the evaluated root modules which did not load, in file
+// order. The scanner only writes the reason to the debug log.
+func findFailedModuleCalls(roots []terraform.Modules) []failedModuleCall {
+	var calls []failedModuleCall
+	for _, modules := range roots {
+		loaded := loadedModuleCalls(modules)
+		reported := make(map[string]bool)
+		for _, module := range modules {
+			for _, block := range module.GetBlocks().OfType("module") {
+				rng := block.GetMetadata().Range().String()
+				if loaded[rng] || reported[rng] {
+					continue
+				}
+				reported[rng] = true
+				call := failedModuleCall{block: block}
+				if attribute := block.GetAttribute("source"); attribute != nil {
+					if value := attribute.Value(); value.Type() == cty.String && value.IsKnown() && !value.IsNull() {
+						call.source = value.AsString()
+					}
+				}
+				calls = append(calls, call)
+			}
+		}
+	}
+	sort.SliceStable(calls, func(i, j int) bool {
+		return declaredBefore(calls[i].block, calls[j].block)
+	})
+	return calls
+}
+
+// findModuleLoadErrors returns a failed result for each of the module calls which did not load.
+func findModuleLoadErrors(calls []failedModuleCall) scan.Results {
+	var results scan.Results
+	for _, call := range calls {
+		results.Add(call.String(), call.block)
+	}
+	return results
+}
+
+// loadedModuleCalls returns the ranges of the module calls which loaded, as the blocks of a loaded module have
+// the module call as their parent.
+func loadedModuleCalls(modules terraform.Modules) map[string]bool {
+	loaded := make(map[string]bool)
+	for _, module := range modules {
+		for _, block := range module.GetBlocks() {
+			if parent := block.GetMetadata().Parent(); parent != nil {
+				loaded[parent.Range().String()] = true
+			}
+		}
+	}
+	return loaded
+}
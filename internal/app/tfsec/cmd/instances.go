@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/terraform"
+)
+
+// mergeInstancesFunc collapses results which differ only by the count/for_each instance key of the
+// resource or module(s) they were found in. The first result is kept and its description lists the
+// keys of every instance that was merged into it, in sorted order.
+func mergeInstancesFunc() func(results scan.Results) scan.Results {
+	return func(results scan.Results) scan.Results {
+		var merged scan.Results
+		indexes := make(map[string]int)
+		instances := make(map[int][]string)
+		for _, result := range results {
+			address, key := instanceAddress(result)
+			if key == "" {
+				merged = append(merged, result)
+				continue
+			}
+			groupKey := fmt.Sprintf("%s|%d|%s|%s", result.Rule().LongID(), result.Status(), result.Range(), address)
+			if index, ok := indexes[groupKey]; ok {
+				instances[index] = append(instances[index], key)
+				continue
+			}
+			indexes[groupKey] = len(merged)
+			instances[len(merged)] = []string{key}
+			merged = append(merged, result)
+		}
+		for index, keys := range instances {
+			if len(keys) < 2 {
+				continue
+			}
+			sort.Strings(keys)
+			merged[index].OverrideDescription(
+				fmt.Sprintf("%s (instances: %s)", merged[index].Description(), strings.Join(keys, ", ")),
+			)
+		}
+		return merged
+	}
+}
+
+// instanceAddress returns the address of the block which caused the result with all instance keys
+// removed, along with the instance keys themselves, outermost module first.
+func instanceAddress(result scan.Result) (string, string) {
+	var parts []string
+	var keys []string
+	for m := result.Metadata(); ; m = *m.Parent() {
+		if ref, ok := m.Reference().(*terraform.Reference); ok {
+			address := ref.String()
+			if bracketed := ref.KeyBracketed(); bracketed != "" {
+				address = strings.Replace(address, bracketed, "", 1)
+				keys = append([]string{ref.Key()}, keys...)
+			}
+			parts = append([]string{address}, parts...)
+		}
+		if m.Parent() == nil {
+			break
+		}
+	}
+	return strings.Join(parts, ":"), strings.Join(keys, "/")
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	getter "github.com/hashicorp/go-getter"
+)
+
+// lfsGitGetter wraps the git getter used to download modules so that Git LFS files are fetched after a
+// repository is cloned. Without this, a repository using LFS leaves pointer files in place of the real
+// content, which breaks functions such as file() in the module.
+type lfsGitGetter struct {
+	getter.Getter
+	warnings io.Writer
+}
+
+// useGitLFS installs or removes the LFS aware git getter. The getters are shared by every module download,
+// so the getter is replaced rather than wrapped again if it has already been installed.
+func useGitLFS(enabled bool, warnings io.Writer) {
+	current := getter.Getters["git"]
+	if wrapped, ok := current.(*lfsGitGetter); ok {
+		current = wrapped.Getter
+	}
+	if enabled {
+		current = &lfsGitGetter{Getter: current, warnings: warnings}
+	}
+	getter.Getters["git"] = current
+}
+
+func (g *lfsGitGetter) Get(dst string, u *url.URL) error {
+	if err := g.Getter.Get(dst, u); err != nil {
+		return err
+	}
+	if !usesGitLFS(dst) {
+		return nil
+	}
+	if err := pullGitLFS(dst); err != nil {
+		// remove the clone so that it is not used from the module cache in later scans
+		_ = os.RemoveAll(dst)
+		_, _ = fmt.Fprintf(g.warnings, "WARNING: The module at %s uses Git LFS and could not be loaded: %s\n", u.Redacted(), err)
+		return err
+	}
+	return nil
+}
+
+// usesGitLFS checks whether the .gitattributes file of the repository cloned into dir assigns the LFS filter.
+func usesGitLFS(dir string) bool {
+	attributes, err := os.ReadFile(filepath.Join(dir, ".gitattributes"))
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(attributes, []byte("filter=lfs"))
+}
+
+func pullGitLFS(dir string) error {
+	if _, err := exec.LookPath("git-lfs"); err != nil {
+		return fmt.Errorf("git-lfs is not installed. Install it, or use --no-git-lfs to load the module without its LFS files")
+	}
+	cmd := exec.Command("git", "lfs", "pull")
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git lfs pull failed: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"bytes"
+	"io/fs"
+	"time"
+)
+
+// memoryFile is a read-only file held in memory, for content which is added to the scanned filesystem.
+type memoryFile struct {
+	*bytes.Reader
+	info memoryFileInfo
+}
+
+func newMemoryFile(name string, data []byte) *memoryFile {
+	return &memoryFile{
+		Reader: bytes.NewReader(data),
+		info:   memoryFileInfo{name: name, size: len(data)},
+	}
+}
+
+func (f *memoryFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *memoryFile) Close() error {
+	return nil
+}
+
+type memoryFileInfo struct {
+	name string
+	size int
+}
+
+func (i memoryFileInfo) Name() string       { return i.name }
+func (i memoryFileInfo) Size() int64        { return int64(i.size) }
+func (i memoryFileInfo) Mode() fs.FileMode  { return 0o444 }
+func (i memoryFileInfo) ModTime() time.Time { return time.Time{} }
+func (i memoryFileInfo) IsDir() bool        { return false }
+func (i memoryFileInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aquasecurity/defsec/pkg/providers"
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/severity"
+	"github.com/aquasecurity/defsec/pkg/terraform"
+)
+
+var moduleCallRule = scan.Rule{
+	ShortCode:   "module-call-arguments",
+	Summary:     "Module call arguments do not match the variables of the module",
+	Explanation: "The module block sets an argument which is not a variable of the module, or does not set a variable of the module which has no default. Terraform rejects the configuration, and the module may not be evaluated as it will be deployed.",
+	Impact:      "The configuration is invalid, and the results for the module may be incomplete",
+	Resolution:  "Set each required variable of the module, and remove arguments which the module does not declare",
+	Provider:    providers.GeneralProvider,
+	Service:     "terraform",
+	Links:       []string{"https://developer.hashicorp.com/terraform/language/modules/syntax#calling-a-child-module"},
+	Severity:    severity.Medium,
+}
+
+// moduleMetaArguments are the arguments of a module block which are not passed to the module as variables.
+var moduleMetaArguments = map[string]bool{
+	"source":     true,
+	"version":    true,
+	"count":      true,
+	"for_each":   true,
+	"providers":  true,
+	"depends_on": true,
+}
+
+// findModuleCallErrors compares the arguments of each module call which loaded with the variables declared
+// by the module, as terraform validate does, and returns a failed result for each argument which is not a
+// variable of the module and for each required variable which is not set.
+func findModuleCallErrors(roots []terraform.Modules) scan.Results {
+	var results scan.Results
+	for _, modules := range roots {
+		loaded := loadedModuleCalls(modules)
+		variables := make(map[string]map[string]*terraform.Block)
+		for _, module := range modules {
+			for _, block := range module.GetBlocks().OfType("variable") {
+				parent := block.GetMetadata().Parent()
+				if parent == nil {
+					continue
+				}
+				call := parent.Range().String()
+				if variables[call] == nil {
+					variables[call] = make(map[string]*terraform.Block)
+				}
+				variables[call][block.Label()] = block
+			}
+		}
+
+		reported := make(map[string]bool)
+		for _, module := range modules {
+			for _, block := range module.GetBlocks().OfType("module") {
+				call := block.GetMetadata().Range().String()
+				if !loaded[call] || reported[call] {
+					continue
+				}
+				reported[call] = true
+
+				arguments := block.Attributes()
+				var names []string
+				for name := range arguments {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					if moduleMetaArguments[name] || variables[call][name] != nil {
+						continue
+					}
+					results.Add(fmt.Sprintf("Module '%s' is called with the argument '%s', which is not a variable of the module.", block.FullName(), name), arguments[name])
+				}
+
+				names = nil
+				for name := range variables[call] {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					if arguments[name] != nil || variables[call][name].HasChild("default") {
+						continue
+					}
+					results.Add(fmt.Sprintf("Module '%s' does not set the required variable '%s'.", block.FullName(), name), block)
+				}
+			}
+		}
+	}
+	return results
+}
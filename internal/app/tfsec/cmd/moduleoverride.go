@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/extrafs"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const modulesMetadataPath = ".terraform/modules/modules.json"
+
+// moduleOverride points the module with the given key at a local directory, whatever its declared source.
+type moduleOverride struct {
+	key string
+	dir string
+}
+
+// parseModuleOverrides reads name=path values. The name is the key terraform uses for the module in
+// .terraform/modules/modules.json, such as network.subnets for module "subnets" called from module
+// "network", and may also be given as the module address, such as module.network.module.subnets.
+func parseModuleOverrides(values []string, fsRoot string) ([]moduleOverride, error) {
+	var overrides []moduleOverride
+	for _, value := range values {
+		name, dir, ok := strings.Cut(value, "=")
+		if !ok || name == "" || dir == "" {
+			return nil, fmt.Errorf("module override '%s' should be given as name=path", value)
+		}
+		var parts []string
+		for _, part := range strings.Split(name, ".") {
+			if part != "module" {
+				parts = append(parts, part)
+			}
+		}
+		fixedDir, err := makePathRelativeToFSRoot(fsRoot, dir)
+		if err != nil {
+			return nil, fmt.Errorf("module override problem: %w", err)
+		}
+		overrides = append(overrides, moduleOverride{key: strings.Join(parts, "."), dir: filepath.ToSlash(fixedDir)})
+	}
+	return overrides, nil
+}
+
+// moduleOverrideFS adds the overridden modules to the .terraform/modules/modules.json file of each root
+// module, as if they had been installed there by terraform init. The parser consults that file before the
+// module source, so the overridden directory is loaded instead. Any modules which were installed by terraform
+// init are kept.
+type moduleOverrideFS struct {
+	underlying extrafs.FS
+	overrides  []moduleOverride
+}
+
+func newModuleOverrideFS(underlying extrafs.FS, overrides []moduleOverride) *moduleOverrideFS {
+	return &moduleOverrideFS{
+		underlying: underlying,
+		overrides:  overrides,
+	}
+}
+
+func (m *moduleOverrideFS) Open(name string) (fs.File, error) {
+	name = path.Clean(name)
+	if name != modulesMetadataPath && !strings.HasSuffix(name, "/"+modulesMetadataPath) {
+		return m.underlying.Open(name)
+	}
+	data, err := m.metadata(name)
+	if err != nil {
+		return nil, err
+	}
+	return newMemoryFile(path.Base(name), data), nil
+}
+
+func (m *moduleOverrideFS) Stat(name string) (fs.FileInfo, error) {
+	name = path.Clean(name)
+	if name != modulesMetadataPath && !strings.HasSuffix(name, "/"+modulesMetadataPath) {
+		return m.underlying.Stat(name)
+	}
+	data, err := m.metadata(name)
+	if err != nil {
+		return nil, err
+	}
+	return memoryFileInfo{name: path.Base(name), size: len(data)}, nil
+}
+
+func (m *moduleOverrideFS) ResolveSymlink(name, dir string) (string, error) {
+	return m.underlying.ResolveSymlink(name, dir)
+}
+
+// metadata returns the modules.json at the given path with the overridden modules added. The directory of
+// each module is relative to the root module, which is the directory containing .terraform.
+func (m *moduleOverrideFS) metadata(name string) ([]byte, error) {
+	projectRoot := path.Dir(path.Dir(path.Dir(name)))
+
+	metadata := make(map[string]interface{})
+	if existing, err := fs.ReadFile(m.underlying, name); err == nil {
+		if err := json.Unmarshal(existing, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+	}
+	overridden := make(map[string]bool)
+	for _, override := range m.overrides {
+		overridden[override.key] = true
+	}
+	existing, _ := metadata["Modules"].([]interface{})
+	var modules []interface{}
+	for _, module := range existing {
+		if entry, ok := module.(map[string]interface{}); ok && overridden[fmt.Sprint(entry["Key"])] {
+			continue
+		}
+		modules = append(modules, module)
+	}
+	for _, override := range m.overrides {
+		overrideDir, err := m.moduleDir(projectRoot, override)
+		if err != nil {
+			return nil, err
+		}
+		dir, err := filepath.Rel(projectRoot, overrideDir)
+		if err != nil {
+			return nil, fmt.Errorf("module override for '%s': %w", override.key, err)
+		}
+		modules = append(modules, map[string]interface{}{
+			"Key":    override.key,
+			"Source": override.dir,
+			"Dir":    filepath.ToSlash(dir),
+		})
+	}
+	metadata["Modules"] = modules
+	return json.Marshal(metadata)
+}
+
+// check returns an error for any override which cannot be applied to the root modules below dir. Otherwise,
+// the parser would ignore the metadata for every module.
+func (m *moduleOverrideFS) check(dir string) error {
+	for _, root := range findRootModules(m.underlying, dir) {
+		for _, override := range m.overrides {
+			if _, err := m.moduleDir(filepath.ToSlash(root), override); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// moduleDir returns the directory to give for the override in modules.json. The parser appends the
+// subdirectory of a source such as github.com/org/repo//modules/x to the directory it finds there, so the
+// overridden directory may be either the module itself, which then has the subdirectory removed, or a
+// checkout of the repository with the module in the subdirectory.
+func (m *moduleOverrideFS) moduleDir(projectRoot string, override moduleOverride) (string, error) {
+	source, ok := m.declaredSource(projectRoot, override.key)
+	if !ok {
+		return override.dir, nil
+	}
+	subdir := appendedSubdir(source)
+	switch {
+	case subdir == "":
+		return override.dir, nil
+	case strings.HasSuffix(override.dir, "/"+subdir):
+		return strings.TrimSuffix(override.dir, "/"+subdir), nil
+	}
+	if info, err := fs.Stat(m.underlying, path.Join(override.dir, subdir)); err == nil && info.IsDir() {
+		return override.dir, nil
+	}
+	return "", fmt.Errorf("module override for '%s' cannot be applied: the source '%s' selects the subdirectory '%s', so the directory given should either end with it or contain it", override.key, source, subdir)
+}
+
+// appendedSubdir returns the part of the source which the parser appends to the directory of a module found in
+// modules.json. It does so for sources with a host and two path segments before the double slash, such as
+// github.com/org/repo//modules/x, including any query string.
+func appendedSubdir(source string) string {
+	if strings.HasPrefix(source, ".") {
+		return ""
+	}
+	if prefix, subdir, ok := strings.Cut(source, "//"); ok && !strings.HasSuffix(prefix, ":") && strings.Count(prefix, "/") == 2 {
+		return subdir
+	}
+	return ""
+}
+
+// declaredSource finds the source of the module call with the given key, starting from the root module in
+// projectRoot. Module calls are followed through local sources and other overrides only, as the files of
+// a remote module are not available before it is downloaded.
+func (m *moduleOverrideFS) declaredSource(projectRoot, key string) (string, bool) {
+	dir := projectRoot
+	parts := strings.Split(key, ".")
+	for i, name := range parts {
+		source, ok := moduleCallSource(m.underlying, dir, name)
+		if !ok {
+			return "", false
+		}
+		if i == len(parts)-1 {
+			return source, true
+		}
+		var overridden bool
+		for _, override := range m.overrides {
+			if override.key == strings.Join(parts[:i+1], ".") {
+				dir, overridden = override.dir, true
+			}
+		}
+		switch {
+		case overridden:
+		case strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../"):
+			dir = path.Join(dir, source)
+		default:
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// moduleCallSource returns the source of the module call with the given name in dir, if it is a literal string.
+func moduleCallSource(target fs.FS, dir, name string) (string, bool) {
+	entries, err := fs.ReadDir(target, dir)
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !isTerraformFile(entry.Name()) {
+			continue
+		}
+		file, _, err := parseTerraformFile(target, path.Join(dir, entry.Name()))
+		if err != nil || file == nil {
+			continue
+		}
+		content, _, _ := file.Body.PartialContent(moduleSourceSchema)
+		for _, block := range content.Blocks {
+			if block.Type != "module" || block.Labels[0] != name {
+				continue
+			}
+			attributes, _ := block.Body.JustAttributes()
+			attribute, ok := attributes["source"]
+			if !ok {
+				return "", false
+			}
+			value, diags := attribute.Expr.Value(nil)
+			if diags.HasErrors() || value.Type() != cty.String || value.IsNull() {
+				return "", false
+			}
+			return value.AsString(), true
+		}
+	}
+	return "", false
+}
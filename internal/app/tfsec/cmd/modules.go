@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/terraform"
+)
+
+// modulePrefixFunc ignores results which were not found within one of the given module addresses.
+// A prefix matches the module itself, every instance of it and any modules nested inside it.
+func modulePrefixFunc(prefixes []string) func(results scan.Results) scan.Results {
+	return func(results scan.Results) scan.Results {
+		for i, result := range results {
+			address := moduleAddress(result)
+			var matched bool
+			for _, prefix := range prefixes {
+				if withinModule(address, prefix) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				results[i].OverrideStatus(scan.StatusIgnored)
+			}
+		}
+		return results
+	}
+}
+
+// moduleAddress returns the full address of the module the result was found in, outermost module
+// first, e.g. module.network.module.subnets["a"]. Results from the root module have an empty address.
+func moduleAddress(result scan.Result) string {
+	var parts []string
+	for m := result.Metadata(); ; m = *m.Parent() {
+		if ref, ok := m.Reference().(*terraform.Reference); ok && ref.BlockType().Name() == "module" {
+			parts = append([]string{ref.String()}, parts...)
+		}
+		if m.Parent() == nil {
+			break
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+func withinModule(address, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, ".")
+	if prefix == "" || address == prefix {
+		return true
+	}
+	return strings.HasPrefix(address, fmt.Sprintf("%s.", prefix)) || strings.HasPrefix(address, fmt.Sprintf("%s[", prefix))
+}
+
+// moduleChainFunc appends the chain of module calls leading to the resource of each result found within
+// a module to its description, e.g. module.a → module.b → aws_s3_bucket.x.
+func moduleChainFunc() func(results scan.Results) scan.Results {
+	return func(results scan.Results) scan.Results {
+		for i, result := range results {
+			if chain := moduleChain(result); chain != "" {
+				results[i].OverrideDescription(fmt.Sprintf("%s (module chain: %s)", result.Description(), chain))
+			}
+		}
+		return results
+	}
+}
+
+// moduleChain returns the module calls leading to the block which caused the result, outermost module
+// first and followed by the block itself. Results from the root module have an empty chain.
+func moduleChain(result scan.Result) string {
+	modules, block := moduleCalls(result)
+	if len(modules) == 0 {
+		return ""
+	}
+	if block != "" {
+		modules = append(modules, block)
+	}
+	return strings.Join(modules, " → ")
+}
+
+// moduleCalls returns the addresses of the module blocks the result was found via, outermost first, and
+// the address of the block within the innermost module which caused the result.
+func moduleCalls(result scan.Result) ([]string, string) {
+	var modules []string
+	var block string
+	for m := result.Metadata(); ; m = *m.Parent() {
+		if ref, ok := m.Reference().(*terraform.Reference); ok {
+			if ref.BlockType().Name() == "module" {
+				modules = append([]string{ref.String()}, modules...)
+			} else if len(modules) == 0 {
+				block = ref.String()
+			}
+		}
+		if m.Parent() == nil {
+			break
+		}
+	}
+	return modules, block
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/terraform"
+)
+
+// warnFailedModuleCalls writes a warning for each of the module calls which did not load, with the location of
+// the module block relative to dir, and returns the number of warnings written.
+func warnFailedModuleCalls(w io.Writer, calls []failedModuleCall, dir string) int {
+	for _, call := range calls {
+		_, _ = fmt.Fprintf(w, "WARNING: %s %s\n", callLocation(call.block, dir), call)
+	}
+	return len(calls)
+}
+
+// unresolvedSources returns the module calls which did not load because the module block has no source, or its
+// source could not be evaluated to a string.
+func unresolvedSources(calls []failedModuleCall) []failedModuleCall {
+	var unresolved []failedModuleCall
+	for _, call := range calls {
+		if call.source == "" {
+			unresolved = append(unresolved, call)
+		}
+	}
+	return unresolved
+}
+
+// moduleSourceError describes the module calls without a source which could be resolved, to stop the scan with
+// --module-source-errors error.
+func moduleSourceError(calls []failedModuleCall, dir string) error {
+	var locations []string
+	for _, call := range calls {
+		locations = append(locations, fmt.Sprintf("'%s' at %s", call.block.FullName(), callLocation(call.block, dir)))
+	}
+	return fmt.Errorf("the source of module %s could not be resolved", strings.Join(locations, ", "))
+}
+
+func callLocation(call *terraform.Block, dir string) string {
+	rng := call.GetMetadata().Range()
+	filename := rng.GetLocalFilename()
+	if rel, err := filepath.Rel(dir, filename); err == nil {
+		filename = rel
+	}
+	return fmt.Sprintf("%s:%d-%d", filepath.ToSlash(filename), rng.GetStartLine(), rng.GetEndLine())
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var movedSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{{Type: "moved"}},
+}
+
+// move is a moved block, mapping the address an object had previously to the address it has now.
+type move struct {
+	from string
+	to   string
+}
+
+// findMoves returns the moved blocks declared in the root modules below dir, in the order they were found.
+// Moved blocks in other modules are relative to each call of the module, so only root modules are used.
+func findMoves(target fs.FS, dir string) ([]move, error) {
+	var moves []move
+	for _, root := range findRootModules(target, dir) {
+		moduleDir := filepath.ToSlash(root)
+		entries, err := fs.ReadDir(target, moduleDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			filePath := path.Join(moduleDir, entry.Name())
+			if entry.IsDir() || !isTerraformFile(filePath) {
+				continue
+			}
+			fileMoves, err := findFileMoves(target, filePath)
+			if err != nil {
+				return nil, err
+			}
+			moves = append(moves, fileMoves...)
+		}
+	}
+	return moves, nil
+}
+
+func findFileMoves(target fs.FS, filePath string) ([]move, error) {
+	file, _, err := parseTerraformFile(target, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, nil
+	}
+	content, _, _ := file.Body.PartialContent(movedSchema)
+	var moves []move
+	for _, block := range content.Blocks {
+		attributes, _ := block.Body.JustAttributes()
+		from, ok := traversalAddress(attributes["from"])
+		if !ok {
+			continue
+		}
+		to, ok := traversalAddress(attributes["to"])
+		if !ok {
+			continue
+		}
+		moves = append(moves, move{from: from, to: to})
+	}
+	return moves, nil
+}
+
+// traversalAddress formats the attribute as an address such as module.a["x"].aws_s3_bucket.b[0], if the
+// attribute is a plain reference.
+func traversalAddress(attribute *hcl.Attribute) (string, bool) {
+	if attribute == nil {
+		return "", false
+	}
+	traversal, diags := hcl.AbsTraversalForExpr(attribute.Expr)
+	if diags.HasErrors() {
+		return "", false
+	}
+	var address string
+	for _, step := range traversal {
+		switch step := step.(type) {
+		case hcl.TraverseRoot:
+			address = step.Name
+		case hcl.TraverseAttr:
+			address += "." + step.Name
+		case hcl.TraverseIndex:
+			switch {
+			case step.Key.Type().Equals(cty.String):
+				address += fmt.Sprintf("[%q]", step.Key.AsString())
+			case step.Key.Type().Equals(cty.Number):
+				address += fmt.Sprintf("[%s]", step.Key.AsBigFloat().Text('f', -1))
+			default:
+				return "", false
+			}
+		default:
+			return "", false
+		}
+	}
+	return address, true
+}
+
+// applyMoves returns the address an object at the given address has after the moves. A move applies to the
+// address itself, to each instance of it if it has no instance key, and to everything within a moved module.
+// Chained moves are followed whatever order they were declared in.
+func applyMoves(address string, moves []move) string {
+	for i := 0; i <= len(moves); i++ {
+		moved := address
+		for _, m := range moves {
+			switch {
+			case moved == m.from:
+				moved = m.to
+			case strings.HasPrefix(moved, m.from+".") || (!strings.HasSuffix(m.from, "]") && strings.HasPrefix(moved, m.from+"[")):
+				moved = m.to + moved[len(m.from):]
+			}
+		}
+		if moved == address {
+			break
+		}
+		address = moved
+	}
+	return address
+}
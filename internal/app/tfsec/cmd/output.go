@@ -49,7 +49,7 @@ func gatherLinks(result scan.Result) []string {
 	}
 
 	var docsLink []string
-	if result.Rule().Provider == providers.CustomProvider {
+	if result.Rule().Provider == providers.CustomProvider || result.Rule().LongID() == hclErrorRule.LongID() {
 		docsLink = result.Rule().Links
 	} else {
 		docsLink = []string{
@@ -87,7 +87,7 @@ func outputFormat(w io.Writer, addExtension bool, baseFilename, format, fsRoot,
 	case "lovely", "default":
 		alsoStdout = true
 		factory.WithCustomFormatterFunc(formatter.DefaultWithMetrics(metrics, conciseOutput, codeTheme,
-			!disableColours, noCode))
+			!disableColours, noCode, flattenModules))
 	case "json":
 		factory.AsJSON()
 		makeRelative = false
@@ -98,7 +98,7 @@ func outputFormat(w io.Writer, addExtension bool, baseFilename, format, fsRoot,
 	case "junit":
 		factory.AsJUnit()
 	case "text":
-		factory.WithCustomFormatterFunc(formatter.DefaultWithMetrics(metrics, conciseOutput, codeTheme, !disableColours, false)).WithColoursEnabled(false)
+		factory.WithCustomFormatterFunc(formatter.DefaultWithMetrics(metrics, conciseOutput, codeTheme, !disableColours, false, flattenModules)).WithColoursEnabled(false)
 	case "sarif":
 		factory.AsSARIF()
 	case "gif":
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/aquasecurity/defsec/pkg/rules"
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/scanners/options"
+	scanner "github.com/aquasecurity/defsec/pkg/scanners/terraform"
+	"github.com/aquasecurity/defsec/pkg/scanners/terraform/parser"
+	"github.com/aquasecurity/defsec/pkg/state"
+	"github.com/aquasecurity/defsec/pkg/terraform"
+	"github.com/aquasecurity/tfsec/internal/pkg/ignores"
+	"github.com/hashicorp/hcl/v2"
+)
+
+// pendingResults holds the results found before a scan, by rule, for the HCL and module load errors, the
+// module call arguments, the overlapping CIDR blocks and the expired ignores. They are returned by rules
+// registered with the scanner, rather than added to the results afterwards, so that exclusions, severity
+// overrides, results filters such as --exclude-path and --baseline-dir, and the metrics apply to them as they do
+// to any other result. The scanner runs its checks once for each root module, and the results are all returned
+// the first time.
+var pendingResults struct {
+	sync.Mutex
+	results map[string]scan.Results
+}
+
+var registerPendingRules sync.Once
+
+var pendingRules = []scan.Rule{hclErrorRule, moduleLoadErrorRule, moduleCallRule, cidrOverlapRule, expiredIgnoreRule}
+
+// pendingResultsWanted checks whether any of the rules with pending results are enabled.
+func pendingResultsWanted() bool {
+	return hclErrorsAsResults || validateModuleCalls || checkCIDROverlaps || moduleSourceErrors == "finding" || ignoreExpiryWarningDays > 0
+}
+
+// preparePendingResults finds the results below dir for the rules enabled by --hcl-errors-as-results,
+// --module-source-errors finding, --validate-module-calls, --check-cidr-overlaps and --ignore-expiry-warning,
+// and leaves them to be returned by the next scan.
+func preparePendingResults(ctx context.Context, scannerOptions []options.ScannerOption, target fs.FS, dir string) error {
+	registerPendingRules.Do(func() {
+		for _, rule := range pendingRules {
+			rules.Register(rule, takePendingResults(rule.ShortCode))
+		}
+	})
+
+	found := make(map[string]scan.Results)
+	var modules terraform.Modules
+	if hclErrorsAsResults {
+		hclResults, err := findHCLErrors(target, path.Clean(dir))
+		if err != nil {
+			return fmt.Errorf("failed to check for hcl errors: %w", err)
+		}
+		found[hclErrorRule.ShortCode] = hclResults
+	}
+	if pendingResultsWanted() {
+		roots, err := evaluateRootModules(ctx, scannerOptions, target, path.Clean(dir))
+		if err != nil {
+			return fmt.Errorf("failed to evaluate modules: %w", err)
+		}
+		for _, root := range roots {
+			modules = append(modules, root...)
+		}
+		switch {
+		case hclErrorsAsResults:
+			found[moduleLoadErrorRule.ShortCode] = findModuleLoadErrors(findFailedModuleCalls(roots))
+		case moduleSourceErrors == "finding":
+			found[moduleLoadErrorRule.ShortCode] = findModuleLoadErrors(unresolvedSources(findFailedModuleCalls(roots)))
+		}
+		if validateModuleCalls {
+			found[moduleCallRule.ShortCode] = findModuleCallErrors(roots)
+		}
+		if checkCIDROverlaps {
+			found[cidrOverlapRule.ShortCode] = findCIDROverlaps(roots)
+		}
+	}
+	if ignoreExpiryWarningDays > 0 {
+		expiredResults, err := findExpiredIgnores(target, path.Clean(dir), time.Now())
+		if err != nil {
+			return err
+		}
+		found[expiredIgnoreRule.ShortCode] = expiredResults
+	}
+	if !disableIgnores {
+		if err := ignorePendingResults(target, found, modules); err != nil {
+			return err
+		}
+	}
+
+	pendingResults.Lock()
+	defer pendingResults.Unlock()
+	pendingResults.results = found
+	return nil
+}
+
+// clearPendingResults removes any results which were not returned, such as when no root modules were found.
+func clearPendingResults() {
+	pendingResults.Lock()
+	defer pendingResults.Unlock()
+	pendingResults.results = nil
+}
+
+func takePendingResults(shortCode string) func(*state.State) scan.Results {
+	return func(*state.State) scan.Results {
+		pendingResults.Lock()
+		defer pendingResults.Unlock()
+		results := pendingResults.results[shortCode]
+		delete(pendingResults.results, shortCode)
+		return results
+	}
+}
+
+// evaluateRootModules evaluates each root module below dir as the scanner does, returning the modules of each.
+func evaluateRootModules(ctx context.Context, scannerOptions []options.ScannerOption, target fs.FS, dir string) ([]terraform.Modules, error) {
+	collector := &parserOptionsCollector{Scanner: scanner.New()}
+	for _, option := range scannerOptions {
+		option(collector)
+	}
+
+	var roots []terraform.Modules
+	for _, root := range findRootModules(target, dir) {
+		p := parser.New(target, "", collector.options...)
+		if err := p.ParseFS(ctx, root); err != nil {
+			return nil, err
+		}
+		modules, _, err := p.EvaluateAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, modules)
+	}
+	return roots, nil
+}
+
+// ignorePendingResults ignores the results covered by an inline ignore, as the scanner does. The ignores of
+// every evaluated module are used, as the module call of a module which did not load may be in a different
+// root module to the one the results are returned for. The scanner does not load a file with an HCL error, so
+// the ignores in the files with parse errors are read from the files themselves.
+func ignorePendingResults(target fs.FS, found map[string]scan.Results, modules terraform.Modules) error {
+	var moduleIgnores terraform.Ignores
+	for _, module := range modules {
+		moduleIgnores = append(moduleIgnores, module.Ignores()...)
+	}
+	for _, rule := range pendingRules {
+		results := found[rule.ShortCode]
+		covering := moduleIgnores
+		if rule.ShortCode == hclErrorRule.ShortCode {
+			fileIgnores, err := unloadedFileIgnores(target, results)
+			if err != nil {
+				return err
+			}
+			covering = append(fileIgnores, moduleIgnores...)
+		}
+		for i, result := range results {
+			if covering.Covering(modules, result.Metadata(), workspace, rule.LongID()) != nil {
+				results[i].OverrideStatus(scan.StatusIgnored)
+			}
+		}
+	}
+	return nil
+}
+
+// unloadedFileIgnores reads the ignore directives in the files of the results. As with the scanner, a
+// directive on the line before another applies to what the other applies to.
+func unloadedFileIgnores(target fs.FS, results scan.Results) (terraform.Ignores, error) {
+	var found terraform.Ignores
+	read := make(map[string]bool)
+	for _, result := range results {
+		filename := result.Range().GetFilename()
+		if read[filename] {
+			continue
+		}
+		read[filename] = true
+		directives, err := ignores.FindFS(target, filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find ignores: %w", err)
+		}
+		fileIgnores := make(terraform.Ignores, 0, len(directives))
+		for _, directive := range directives {
+			line := sourceBlock(target, hcl.Range{
+				Filename: filename,
+				Start:    hcl.Pos{Line: directive.Line},
+				End:      hcl.Pos{Line: directive.Line},
+			})
+			fileIgnores = append(fileIgnores, terraform.Ignore{
+				Range:     line.GetMetadata().Range(),
+				RuleID:    directive.RuleID,
+				Expiry:    directive.Expiry,
+				Workspace: directive.Workspace,
+				Block:     true,
+				Params:    directive.Params,
+			})
+		}
+		for a := range fileIgnores {
+			for _, other := range fileIgnores {
+				if fileIgnores[a].Range.GetStartLine()+1 == other.Range.GetStartLine() {
+					fileIgnores[a].Range = other.Range
+				}
+			}
+		}
+		found = append(found, fileIgnores...)
+	}
+	return found, nil
+}
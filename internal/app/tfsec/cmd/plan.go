@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"io/fs"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/scanners/terraformplan/parser"
+)
+
+// isPlanFile reports whether the file should be read as the output of `terraform show -json`, which is the
+// case for any json file that is not itself terraform.
+func isPlanFile(name string) bool {
+	return strings.HasSuffix(name, ".json") && !isTerraformFile(name)
+}
+
+// planFileSystem converts a plan in json format into an in-memory filesystem containing the planned
+// resources as terraform, with their values already resolved.
+func planFileSystem(path string) (fs.FS, error) {
+	planFile, err := parser.New().ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return planFile.ToFS()
+}
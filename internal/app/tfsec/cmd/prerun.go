@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -48,16 +49,9 @@ func prerun(cmd *cobra.Command, args []string) error {
 	}
 
 	if migrateIgnores {
-		var dir string
-		var err error
-
-		if len(args) == 1 {
-			dir, err = filepath.Abs(args[0])
-		} else {
-			dir, err = os.Getwd()
-		}
+		dir, err := prerunDir(args)
 		if err != nil {
-			return fmt.Errorf("directory was not provided, and tfsec encountered an error trying to determine the current working directory: %w", err)
+			return err
 		}
 
 		stats, err := ignores.RunMigration(dir)
@@ -72,5 +66,92 @@ func prerun(cmd *cobra.Command, args []string) error {
 		return &ExitCodeError{code: 0}
 	}
 
+	if listIgnores {
+		target, rel, err := prerunTarget(args)
+		if err != nil {
+			return err
+		}
+		directives, err := ignores.FindFS(target, rel)
+		if err != nil {
+			return fmt.Errorf("failed to find ignores: %w", err)
+		}
+		if err := printIgnores(cmd.OutOrStdout(), directives); err != nil {
+			return err
+		}
+		return &ExitCodeError{code: 0}
+	}
+
+	if listUnusedModules {
+		target, rel, err := prerunTarget(args)
+		if err != nil {
+			return err
+		}
+		unused, err := findUnusedModules(target, rel)
+		if err != nil {
+			return fmt.Errorf("failed to find unused modules: %w", err)
+		}
+		for _, moduleDir := range unused {
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), moduleDir)
+		}
+		return &ExitCodeError{code: 0}
+	}
+
+	if listUnusedVariables {
+		target, rel, err := prerunTarget(args)
+		if err != nil {
+			return err
+		}
+		unused, err := findUnusedVariables(target, rel)
+		if err != nil {
+			return fmt.Errorf("failed to find unused variables: %w", err)
+		}
+		for _, variable := range unused {
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s:%d %s\n", variable.Filename, variable.Line, variable.Name)
+		}
+		return &ExitCodeError{code: 0}
+	}
+
+	if listProviderConstraints {
+		target, rel, err := prerunTarget(args)
+		if err != nil {
+			return err
+		}
+		constraints, err := findProviderConstraints(target, rel)
+		if err != nil {
+			return fmt.Errorf("failed to find provider constraints: %w", err)
+		}
+		if err := printProviderConstraints(cmd.OutOrStdout(), constraints); err != nil {
+			return err
+		}
+		return &ExitCodeError{code: 0}
+	}
+
 	return nil
 }
+
+// prerunDir returns the absolute path of the directory or file given to the commands which run in place of a
+// scan, or the current working directory if none was given.
+func prerunDir(args []string) (string, error) {
+	dir, err := os.Getwd()
+	if len(args) == 1 {
+		dir, err = filepath.Abs(args[0])
+	}
+	if err != nil {
+		return "", fmt.Errorf("directory was not provided, and tfsec encountered an error trying to determine the current working directory: %w", err)
+	}
+	return dir, nil
+}
+
+// prerunTarget returns a filesystem for the directory given to the commands which run in place of a scan, and
+// the path to read within it. The path is "." for a directory, and the name of the file if a single file was
+// given, in which case the filesystem is its directory.
+func prerunTarget(args []string) (fs.FS, string, error) {
+	dir, err := prerunDir(args)
+	if err != nil {
+		return nil, "", err
+	}
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		return os.DirFS(filepath.Dir(dir)), filepath.Base(dir), nil
+	}
+	return os.DirFS(dir), ".", nil
+}
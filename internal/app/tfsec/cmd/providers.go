@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var requiredProvidersSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{{Type: "required_providers"}},
+}
+
+type providerConstraint struct {
+	Module   string `json:"module"`
+	Filename string `json:"filename"`
+	Line     int    `json:"line"`
+	Name     string `json:"name"`
+	Source   string `json:"source,omitempty"`
+	Version  string `json:"version,omitempty"`
+}
+
+// findProviderConstraints returns the providers in the required_providers blocks of the modules below dir,
+// with their sources and version constraints.
+func findProviderConstraints(target fs.FS, dir string) ([]providerConstraint, error) {
+	var constraints []providerConstraint
+	err := walkTerraformFiles(target, dir, func(filePath string) error {
+		found, err := findFileProviderConstraints(target, filePath)
+		if err != nil {
+			return err
+		}
+		constraints = append(constraints, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(constraints, func(i, j int) bool {
+		if constraints[i].Filename != constraints[j].Filename {
+			return constraints[i].Filename < constraints[j].Filename
+		}
+		return constraints[i].Line < constraints[j].Line
+	})
+	return constraints, nil
+}
+
+func findFileProviderConstraints(target fs.FS, filePath string) ([]providerConstraint, error) {
+	file, _, err := parseTerraformFile(target, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, nil
+	}
+
+	content, _, _ := file.Body.PartialContent(terraformBlockSchema)
+	var constraints []providerConstraint
+	for _, block := range content.Blocks {
+		blockContent, _, _ := block.Body.PartialContent(requiredProvidersSchema)
+		for _, providersBlock := range blockContent.Blocks {
+			attributes, _ := providersBlock.Body.JustAttributes()
+			for name, attribute := range attributes {
+				constraint := providerConstraint{
+					Module:   path.Dir(filePath),
+					Filename: filePath,
+					Line:     attribute.Range.Start.Line,
+					Name:     name,
+				}
+				// the version may be given on its own, as in terraform 0.12
+				if value, diags := attribute.Expr.Value(nil); !diags.HasErrors() && value.Type() == cty.String && !value.IsNull() {
+					constraint.Version = value.AsString()
+				}
+				pairs, _ := hcl.ExprMap(attribute.Expr)
+				for _, pair := range pairs {
+					key, diags := pair.Key.Value(nil)
+					if diags.HasErrors() || key.Type() != cty.String || key.IsNull() {
+						continue
+					}
+					value, diags := pair.Value.Value(nil)
+					if diags.HasErrors() || value.Type() != cty.String || value.IsNull() {
+						continue
+					}
+					switch key.AsString() {
+					case "source":
+						constraint.Source = value.AsString()
+					case "version":
+						constraint.Version = value.AsString()
+					}
+				}
+				constraints = append(constraints, constraint)
+			}
+		}
+	}
+	return constraints, nil
+}
+
+// printProviderConstraints writes a line for each provider, marking those without a version constraint, or
+// a JSON document when the json format is chosen.
+func printProviderConstraints(w io.Writer, constraints []providerConstraint) error {
+	if format == "json" {
+		if constraints == nil {
+			constraints = []providerConstraint{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			Providers []providerConstraint `json:"providers"`
+		}{constraints})
+	}
+	for _, constraint := range constraints {
+		line := fmt.Sprintf("%s:%d %s", constraint.Filename, constraint.Line, constraint.Name)
+		if constraint.Source != "" {
+			line += " source:" + constraint.Source
+		}
+		if constraint.Version != "" {
+			line += " version:" + constraint.Version
+		} else {
+			line += " unpinned"
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/terraform"
+)
+
+// resourceTypesFunc ignores results which were not caused by a resource of one of the given types, such
+// as aws_s3_bucket. Results from any other kind of block, such as a data source, are ignored too. The
+// results are filtered after the scan, so every block is still evaluated and checked.
+func resourceTypesFunc(types []string) func(results scan.Results) scan.Results {
+	wanted := make(map[string]bool)
+	for _, resourceType := range types {
+		wanted[resourceType] = true
+	}
+	return func(results scan.Results) scan.Results {
+		for i, result := range results {
+			if !wanted[resourceType(result)] {
+				results[i].OverrideStatus(scan.StatusIgnored)
+			}
+		}
+		return results
+	}
+}
+
+// blockTypesFunc ignores results which were not caused by a block of one of the given types, such as resource
+// or data. The results are filtered after the scan, so every block is still evaluated and checked.
+func blockTypesFunc(types []string) func(results scan.Results) scan.Results {
+	wanted := make(map[string]bool)
+	for _, blockType := range types {
+		wanted[blockType] = true
+	}
+	return func(results scan.Results) scan.Results {
+		for i, result := range results {
+			if block := causingBlock(result); block == nil || !wanted[block.BlockType().Name()] {
+				results[i].OverrideStatus(scan.StatusIgnored)
+			}
+		}
+		return results
+	}
+}
+
+// resourceType returns the type of the resource which caused the result, or an empty string if the result
+// was not caused by a resource.
+func resourceType(result scan.Result) string {
+	block := causingBlock(result)
+	if block == nil || block.BlockType().Name() != "resource" {
+		return ""
+	}
+	return block.TypeLabel()
+}
+
+// causingBlock returns the block which caused the result, or nil if the result was not caused by a block. The
+// outermost block within the innermost module is the one which caused it.
+func causingBlock(result scan.Result) *terraform.Reference {
+	var block *terraform.Reference
+	for m := result.Metadata(); ; m = *m.Parent() {
+		if ref, ok := m.Reference().(*terraform.Reference); ok {
+			if ref.BlockType().Name() == "module" {
+				break
+			}
+			block = ref
+		}
+		if m.Parent() == nil {
+			break
+		}
+	}
+	return block
+}
@@ -3,16 +3,22 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver"
 	debugging "github.com/aquasecurity/defsec/pkg/debug"
 	"github.com/aquasecurity/defsec/pkg/extrafs"
+	"github.com/aquasecurity/defsec/pkg/scan"
 	scanner "github.com/aquasecurity/defsec/pkg/scanners/terraform"
 	"github.com/aquasecurity/defsec/pkg/scanners/terraform/executor"
+	"github.com/aquasecurity/tfsec/internal/pkg/atlantis"
 	"github.com/aquasecurity/tfsec/internal/pkg/config"
+	"github.com/aquasecurity/tfsec/internal/pkg/ordering"
 	"github.com/aquasecurity/tfsec/version"
 	"github.com/spf13/cobra"
 )
@@ -37,7 +43,7 @@ var logger debugging.Logger
 
 func Root() *cobra.Command {
 	rootCmd := &cobra.Command{
-		Use:               "tfsec [directory]",
+		Use:               "tfsec [directory|file]",
 		Short:             "tfsec is a terraform security scanner",
 		Long:              `tfsec is a simple tool to detect potential security vulnerabilities in your terraformed infrastructure.`,
 		PersistentPreRunE: prerun,
@@ -55,17 +61,43 @@ func Root() *cobra.Command {
 			// we handle our own errors, and usage does not need to be shown if we've got this far
 			cmd.SilenceUsage = true
 
-			dir, err := findDirectory(args)
+			dir, file, err := findTarget(args)
 			if err != nil {
 				return err
 			}
 
 			logger.Log("Determined path dir=%s", dir)
+			if isPlanFile(file) {
+				logger.Log("Scanning plan file=%s", file)
+			} else if file != "" {
+				logger.Log("Scanning single file file=%s", file)
+			}
 
 			if len(tfvarsPaths) == 0 && unusedTfvarsPresent(dir) {
 				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "WARNING: A tfvars file was found but not automatically used. Did you mean to specify the --tfvars-file flag?\n")
 			}
 
+			if ignoreExpiryWarningDays > 0 && !isPlanFile(file) {
+				if err := warnExpiringIgnores(cmd.ErrOrStderr(), filepath.Join(dir, file), ignoreExpiryWarningDays, time.Now()); err != nil {
+					return err
+				}
+			}
+
+			if !isPlanFile(file) {
+				if err := warnExperiments(cmd.ErrOrStderr(), os.DirFS(dir), path.Join(".", filepath.ToSlash(file))); err != nil {
+					logger.Log("Could not check for experiments: %s", err)
+				}
+			}
+
+			var moduleWarnings int
+			if warnShorthandSources && !isPlanFile(file) {
+				shorthandWarnings, err := warnModuleShorthand(cmd.ErrOrStderr(), os.DirFS(dir), path.Join(".", filepath.ToSlash(file)))
+				if err != nil {
+					return err
+				}
+				moduleWarnings += shorthandWarnings
+			}
+
 			root, rel, err := splitRoot(dir)
 			if err != nil {
 				return err
@@ -74,15 +106,169 @@ func Root() *cobra.Command {
 			logger.Log("Determined path root=%s", root)
 			logger.Log("Determined path rel=%s", rel)
 
-			options, err := configureOptions(cmd, root, dir)
+			stdinVars, err := readStdinVars(cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+
+			options, err := configureOptions(cmd, root, dir, stdinVars)
 			if err != nil {
 				return fmt.Errorf("invalid option: %w", err)
 			}
 
+			useGitLFS(!noGitLFS, cmd.ErrOrStderr())
+
+			var target fs.FS = extrafs.OSDir(root)
+			switch {
+			case isPlanFile(file):
+				target, err = planFileSystem(filepath.Join(dir, file))
+				if err != nil {
+					return fmt.Errorf("failed to read plan: %w", err)
+				}
+				// the planned resources are at the root of the in-memory filesystem
+				root, rel = dir, "."
+			case file != "":
+				target = newSingleFileFS(extrafs.OSDir(root), filepath.ToSlash(filepath.Join(rel, file)))
+				options = append(options, scanner.ScannerWithDownloadsAllowed(false))
+			}
+
+			if stdinVars != nil {
+				osTarget, ok := target.(extrafs.FS)
+				if !ok {
+					return fmt.Errorf("--var-file=- cannot be used when scanning a plan file")
+				}
+				target = newStdinVarsFS(osTarget, stdinVars)
+			}
+
+			if len(moduleOverrides) > 0 {
+				overrides, err := parseModuleOverrides(moduleOverrides, root)
+				if err != nil {
+					return fmt.Errorf("invalid option: %w", err)
+				}
+				osTarget, ok := target.(extrafs.FS)
+				if !ok {
+					return fmt.Errorf("--module-override cannot be used when scanning a plan file")
+				}
+				overrideTarget := newModuleOverrideFS(osTarget, overrides)
+				if err := overrideTarget.check(rel); err != nil {
+					return fmt.Errorf("invalid option: %w", err)
+				}
+				target = overrideTarget
+			}
+
+			if printEvaluatedConfig {
+				if err := writeEvaluatedConfig(cmd.OutOrStdout(), options, target, rel); err != nil {
+					return fmt.Errorf("failed to evaluate configuration: %w", err)
+				}
+				return nil
+			}
+
+			if !lenientModuleSources && !isPlanFile(file) {
+				calls, err := findComputedSources(target, rel)
+				if err != nil {
+					return fmt.Errorf("failed to find module sources: %w", err)
+				}
+				moduleWarnings += warnComputedSources(cmd.ErrOrStderr(), calls, rel)
+				options = append(options, scanner.ScannerWithResultsFilter(excludeModuleCalls(calls)))
+			}
+
+			var base *baseline
+			if baselineDir != "" {
+				absBaseline, err := filepath.Abs(baselineDir)
+				if err != nil {
+					return fmt.Errorf("invalid baseline directory: %w", err)
+				}
+				baselineRoot, baselineRel, err := splitRoot(absBaseline)
+				if err != nil {
+					return err
+				}
+				var baselineTarget extrafs.FS = extrafs.OSDir(baselineRoot)
+				if stdinVars != nil {
+					baselineTarget = newStdinVarsFS(baselineTarget, stdinVars)
+				}
+				moves, err := findMoves(target, rel)
+				if err != nil {
+					return fmt.Errorf("failed to find moved blocks: %w", err)
+				}
+				if pendingResultsWanted() {
+					if err := preparePendingResults(context.TODO(), options, baselineTarget, baselineRel); err != nil {
+						return fmt.Errorf("baseline scan failed: %w", err)
+					}
+				}
+				baselineOptions := options
+				if !lenientModuleSources {
+					calls, err := findComputedSources(baselineTarget, baselineRel)
+					if err != nil {
+						return fmt.Errorf("failed to find module sources: %w", err)
+					}
+					baselineOptions = append(baselineOptions, scanner.ScannerWithResultsFilter(excludeModuleCalls(calls)))
+				}
+				base, err = loadBaseline(context.TODO(), baselineOptions, baselineTarget, baselineRel, moves)
+				if err != nil {
+					return fmt.Errorf("baseline scan failed: %w", err)
+				}
+				options = append(options, scanner.ScannerWithResultsFilter(base.filter))
+			}
+
+			if pendingResultsWanted() {
+				if err := preparePendingResults(context.TODO(), options, target, rel); err != nil {
+					return fmt.Errorf("scan failed: %w", err)
+				}
+				defer clearPendingResults()
+			}
+
+			if strictModuleWarnings || moduleSourceErrors == "error" {
+				roots, err := evaluateRootModules(context.TODO(), options, target, rel)
+				if err != nil {
+					return fmt.Errorf("failed to evaluate modules: %w", err)
+				}
+				calls := findFailedModuleCalls(roots)
+				if unresolved := unresolvedSources(calls); moduleSourceErrors == "error" && len(unresolved) > 0 {
+					return moduleSourceError(unresolved, rel)
+				}
+				if strictModuleWarnings {
+					moduleWarnings += warnFailedModuleCalls(cmd.ErrOrStderr(), calls, rel)
+				}
+			}
+
 			scnr := scanner.New(options...)
-			results, metrics, err := scnr.ScanFSWithMetrics(context.TODO(), extrafs.OSDir(root), rel)
-			if err != nil {
-				return fmt.Errorf("scan failed: %w", err)
+			var results scan.Results
+			var metrics scanner.Metrics
+			if atlantisProjects {
+				if file != "" {
+					return fmt.Errorf("--atlantis-projects requires a directory to be scanned")
+				}
+				configPath, err := atlantis.FindConfig(dir)
+				if err != nil {
+					return err
+				}
+				config, err := atlantis.LoadConfig(configPath)
+				if err != nil {
+					return err
+				}
+				results, metrics, err = scanAtlantisProjects(context.TODO(), options, target, rel, config)
+				if err != nil {
+					return fmt.Errorf("scan failed: %w", err)
+				}
+			} else {
+				results, metrics, err = scnr.ScanFSWithMetrics(context.TODO(), target, rel)
+				if err != nil {
+					return fmt.Errorf("scan failed: %w", err)
+				}
+			}
+
+			if includeExamples {
+				exampleResults, err := scanExamples(context.TODO(), scnr, target, rel, &metrics)
+				if err != nil {
+					return fmt.Errorf("scan failed: %w", err)
+				}
+				results = append(results, exampleResults...)
+			}
+
+			ordering.Sort(results)
+
+			if base != nil {
+				_, _ = fmt.Fprintln(cmd.ErrOrStderr(), base.summary())
 			}
 
 			if printRegoInput {
@@ -106,6 +292,13 @@ func Root() *cobra.Command {
 				return fmt.Errorf("failed to write output: %w", err)
 			}
 
+			if strictModuleWarnings && moduleWarnings > 0 {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "The scan failed because of %d module warning(s) with --strict-module-warnings\n", moduleWarnings)
+				return &ExitCodeError{
+					code: 1,
+				}
+			}
+
 			if exitCode != 0 && !softFail {
 				return &ExitCodeError{
 					code: exitCode,
@@ -182,31 +375,38 @@ func splitRoot(dir string) (string, string, error) {
 	return root, rel, nil
 }
 
-func findDirectory(args []string) (string, error) {
+// findTarget returns the directory to scan. If the provided path is a single terraform file or a plan
+// in json format, its directory is returned along with the base name of the file.
+func findTarget(args []string) (string, string, error) {
 	var dir string
 	workingDir, err := os.Getwd()
 	if err != nil {
-		return "", fmt.Errorf("could not determine current directory: %w", err)
+		return "", "", fmt.Errorf("could not determine current directory: %w", err)
 	}
 
 	if len(args) > 1 {
-		return "", fmt.Errorf("unexpected input - you must specify at most one directory to scan")
+		return "", "", fmt.Errorf("unexpected input - you must specify at most one directory or file to scan")
 	}
 
 	if len(args) == 1 {
 		dir, err = filepath.Abs(filepath.Clean(args[0]))
 		if err != nil {
-			return "", fmt.Errorf("could not determine absolute path for provided path: %w", err)
+			return "", "", fmt.Errorf("could not determine absolute path for provided path: %w", err)
 		}
 	} else {
 		dir = workingDir
 	}
 
-	if dirInfo, err := os.Stat(dir); err != nil {
-		return "", fmt.Errorf("failed to access provided path: %w", err)
-	} else if !dirInfo.IsDir() {
-		return "", fmt.Errorf("provided path is not a dir")
+	dirInfo, err := os.Stat(dir)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to access provided path: %w", err)
+	}
+	if dirInfo.IsDir() {
+		return dir, "", nil
+	}
+	if !isTerraformFile(dir) && !isPlanFile(dir) {
+		return "", "", fmt.Errorf("provided path is not a dir, a terraform file or a terraform plan in json format")
 	}
 
-	return dir, nil
+	return filepath.Dir(dir), filepath.Base(dir), nil
 }
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/extrafs"
+)
+
+// findRootModules returns the directories below dir which the scanner treats as root modules, in the order
+// it scans them: the shallowest directories which directly contain terraform files, or every such directory
+// with --force-all-dirs. This follows the discovery in the scanner, which is not exported.
+func findRootModules(target fs.FS, dir string) []string {
+	roots := removeNestedDirs(findRootModuleDirs(target, dir, dir))
+	sort.Strings(roots)
+	return roots
+}
+
+func findRootModuleDirs(target fs.FS, scanDir string, dirs ...string) []string {
+	var roots []string
+	var others []string
+	for _, dir := range dirs {
+		if containsTerraformFiles(target, dir) {
+			roots = append(roots, dir)
+			if !allDirs {
+				continue
+			}
+		}
+		entries, err := fs.ReadDir(target, filepath.ToSlash(dir))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			realPath := filepath.Join(dir, entry.Name())
+			if linkFS, ok := target.(extrafs.ReadLinkFS); ok {
+				realPath, err = linkFS.ResolveSymlink(realPath, scanDir)
+				if err != nil {
+					continue
+				}
+			}
+			if entry.IsDir() {
+				others = append(others, realPath)
+			} else if info, err := fs.Stat(target, filepath.ToSlash(realPath)); err == nil && info.IsDir() {
+				others = append(others, realPath)
+			}
+		}
+	}
+	if (len(roots) == 0 || allDirs) && len(others) > 0 {
+		roots = append(roots, findRootModuleDirs(target, scanDir, others...)...)
+	}
+	return roots
+}
+
+func containsTerraformFiles(target fs.FS, dir string) bool {
+	entries, err := fs.ReadDir(target, filepath.ToSlash(dir))
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if isTerraformFile(entry.Name()) {
+			return true
+		}
+	}
+	return false
+}
+
+func removeNestedDirs(dirs []string) []string {
+	if allDirs {
+		return dirs
+	}
+	var clean []string
+	for _, dirA := range dirs {
+		nested := false
+		for _, dirB := range dirs {
+			if dirA == dirB {
+				continue
+			}
+			if rel, err := filepath.Rel(dirB, dirA); err == nil && !strings.HasPrefix(rel, "..") {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			clean = append(clean, dirA)
+		}
+	}
+	return clean
+}
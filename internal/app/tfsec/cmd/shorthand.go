@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// shorthandHosts are the hosts terraform recognises in a module source without a git:: or https:// prefix.
+var shorthandHosts = []string{"github.com/", "bitbucket.org/"}
+
+type shorthandSource struct {
+	Range  hcl.Range
+	Module string
+	Source string
+}
+
+// warnModuleShorthand writes an advisory for each module call below dir with a github.com or bitbucket.org
+// shorthand source. These look like registry addresses, and are cloned from the default branch unless a ref
+// is given. It returns the number of advisories written.
+func warnModuleShorthand(w io.Writer, target fs.FS, dir string) (int, error) {
+	sources, err := findShorthandSources(target, dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find module sources: %w", err)
+	}
+	for _, source := range sources {
+		_, _ = fmt.Fprintf(w, "WARNING: %s:%d-%d module '%s' uses the shorthand source '%s'. Use an explicit source such as '%s' with a pinned ref instead.\n",
+			source.Range.Filename, source.Range.Start.Line, source.Range.End.Line, source.Module, source.Source, explicitSource(source.Source))
+	}
+	return len(sources), nil
+}
+
+// findShorthandSources returns the module calls below dir which use a shorthand source, in file order.
+func findShorthandSources(target fs.FS, dir string) ([]shorthandSource, error) {
+	var sources []shorthandSource
+	err := walkTerraformFiles(target, dir, func(filePath string) error {
+		found, err := findFileShorthandSources(target, filePath)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, found...)
+		return nil
+	})
+	sort.SliceStable(sources, func(i, j int) bool {
+		if sources[i].Range.Filename != sources[j].Range.Filename {
+			return sources[i].Range.Filename < sources[j].Range.Filename
+		}
+		return sources[i].Range.Start.Line < sources[j].Range.Start.Line
+	})
+	return sources, err
+}
+
+func findFileShorthandSources(target fs.FS, filePath string) ([]shorthandSource, error) {
+	file, _, err := parseTerraformFile(target, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, nil
+	}
+
+	content, _, _ := file.Body.PartialContent(moduleSourceSchema)
+	var sources []shorthandSource
+	for _, block := range content.Blocks {
+		if block.Type != "module" {
+			continue
+		}
+		attributes, _ := block.Body.JustAttributes()
+		attribute, ok := attributes["source"]
+		if !ok {
+			continue
+		}
+		value, diags := attribute.Expr.Value(nil)
+		if diags.HasErrors() || value.Type() != cty.String || value.IsNull() {
+			continue
+		}
+		if explicitSource(value.AsString()) == "" {
+			continue
+		}
+		rng := block.DefRange
+		if body, ok := block.Body.(interface{ Range() hcl.Range }); ok {
+			rng = hcl.RangeBetween(block.DefRange, body.Range())
+		}
+		sources = append(sources, shorthandSource{
+			Range:  rng,
+			Module: block.Labels[0],
+			Source: value.AsString(),
+		})
+	}
+	return sources, nil
+}
+
+// explicitSource returns the git source for a shorthand source, keeping any subdirectory, or an empty string
+// if the source is not a shorthand.
+func explicitSource(source string) string {
+	for _, host := range shorthandHosts {
+		if strings.HasPrefix(source, host) {
+			repository, _, _ := strings.Cut(source, "?")
+			repository, subdir, _ := strings.Cut(repository, "//")
+			if subdir != "" {
+				subdir = "//" + subdir
+			}
+			return fmt.Sprintf("git::https://%s.git%s?ref=<tag>", strings.TrimSuffix(repository, ".git"), subdir)
+		}
+	}
+	return ""
+}
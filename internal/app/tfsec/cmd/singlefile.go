@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/extrafs"
+)
+
+// singleFileFS exposes the underlying filesystem with every terraform file except one hidden, along with
+// any .terraform directories. Module blocks in the remaining file therefore have nothing to load and are
+// left unresolved, but other inputs such as tfvars and config files can still be read.
+type singleFileFS struct {
+	underlying extrafs.FS
+	file       string
+}
+
+func newSingleFileFS(underlying extrafs.FS, file string) *singleFileFS {
+	return &singleFileFS{
+		underlying: underlying,
+		file:       path.Clean(file),
+	}
+}
+
+func isTerraformFile(name string) bool {
+	return strings.HasSuffix(name, ".tf") || strings.HasSuffix(name, ".tf.json")
+}
+
+func (s *singleFileFS) hidden(name string) bool {
+	name = path.Clean(name)
+	for _, part := range strings.Split(name, "/") {
+		if part == ".terraform" {
+			return true
+		}
+	}
+	return name != s.file && isTerraformFile(name)
+}
+
+func (s *singleFileFS) Open(name string) (fs.File, error) {
+	if s.hidden(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	f, err := s.underlying.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if dir, ok := f.(fs.ReadDirFile); ok {
+		return &singleFileDir{ReadDirFile: dir, fs: s, name: name}, nil
+	}
+	return f, nil
+}
+
+func (s *singleFileFS) Stat(name string) (fs.FileInfo, error) {
+	if s.hidden(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return s.underlying.Stat(name)
+}
+
+func (s *singleFileFS) ResolveSymlink(name, dir string) (string, error) {
+	return s.underlying.ResolveSymlink(name, dir)
+}
+
+type singleFileDir struct {
+	fs.ReadDirFile
+	fs   *singleFileFS
+	name string
+}
+
+func (d *singleFileDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	entries, err := d.ReadDirFile.ReadDir(n)
+	var visible []fs.DirEntry
+	for _, entry := range entries {
+		if !d.fs.hidden(path.Join(d.name, entry.Name())) {
+			visible = append(visible, entry)
+		}
+	}
+	return visible, err
+}
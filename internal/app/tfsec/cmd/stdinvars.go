@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+
+	"github.com/aquasecurity/defsec/pkg/extrafs"
+)
+
+// stdinVars holds tfvars read from stdin for --var-file=-. The content is never written to disk. Instead it is
+// exposed to the scanner as a file at the root of the scanned filesystem, with a name ending in .json if the
+// content is JSON, as the parser chooses between HCL and JSON by extension.
+type stdinVars struct {
+	name string
+	data []byte
+}
+
+// readStdinVars reads the tfvars content from r if the stdin path was given as a tfvars file. The format is
+// detected from the content unless it was set with --var-file-stdin-format.
+func readStdinVars(r io.Reader) (*stdinVars, error) {
+	var requested bool
+	for _, path := range tfvarsPaths {
+		requested = requested || path == "-"
+	}
+	if !requested {
+		return nil, nil
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tfvars from stdin: %w", err)
+	}
+	vars := &stdinVars{name: ".tfsec-stdin.tfvars", data: data}
+	switch varFileStdinFormat {
+	case "json":
+		vars.name += ".json"
+	case "hcl":
+	case "":
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+			vars.name += ".json"
+		}
+	default:
+		return nil, fmt.Errorf("'%s' is not a valid tfvars format - should be one of hcl, json", varFileStdinFormat)
+	}
+	return vars, nil
+}
+
+type stdinVarsFS struct {
+	underlying extrafs.FS
+	vars       *stdinVars
+}
+
+func newStdinVarsFS(underlying extrafs.FS, vars *stdinVars) *stdinVarsFS {
+	return &stdinVarsFS{
+		underlying: underlying,
+		vars:       vars,
+	}
+}
+
+func (s *stdinVarsFS) Open(name string) (fs.File, error) {
+	if path.Clean(name) == s.vars.name {
+		return newMemoryFile(s.vars.name, s.vars.data), nil
+	}
+	return s.underlying.Open(name)
+}
+
+func (s *stdinVarsFS) Stat(name string) (fs.FileInfo, error) {
+	if path.Clean(name) == s.vars.name {
+		return memoryFileInfo{name: s.vars.name, size: len(s.vars.data)}, nil
+	}
+	return s.underlying.Stat(name)
+}
+
+func (s *stdinVarsFS) ResolveSymlink(name, dir string) (string, error) {
+	return s.underlying.ResolveSymlink(name, dir)
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aquasecurity/defsec/pkg/providers"
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/severity"
+	"github.com/aquasecurity/tfsec/internal/pkg/ignores"
+	"github.com/hashicorp/hcl/v2"
+)
+
+// printIgnores writes the ignore directives as JSON if the json format was requested, or one per line otherwise.
+func printIgnores(w io.Writer, directives ignores.Directives) error {
+	if format == "json" {
+		if directives == nil {
+			directives = ignores.Directives{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			Ignores ignores.Directives `json:"ignores"`
+		}{directives})
+	}
+	for _, directive := range directives {
+		line := fmt.Sprintf("%s:%d %s", directive.Filename, directive.Line, directive.RuleID)
+		if len(directive.Params) > 0 {
+			var params []string
+			for key, val := range directive.Params {
+				params = append(params, key+"="+val)
+			}
+			sort.Strings(params)
+			line += "[" + strings.Join(params, ",") + "]"
+		}
+		if directive.Expiry != nil {
+			line += " expires:" + directive.Expiry.Format("2006-01-02")
+		}
+		if directive.Workspace != "" {
+			line += " workspace:" + directive.Workspace
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var expiredIgnoreRule = scan.Rule{
+	ShortCode:   "expired-ignore",
+	Summary:     "Ignore comment has expired",
+	Explanation: "The ignore comment has an expiry date which has passed, so it no longer suppresses anything and the results it was written for are reported again.",
+	Impact:      "Results which were meant to be revisited by a date are reported without the reason they were ignored",
+	Resolution:  "Fix the issue or remove the ignore",
+	Provider:    providers.GeneralProvider,
+	Service:     "terraform",
+	Links:       []string{"https://aquasecurity.github.io/tfsec/latest/guides/configuration/ignores/"},
+	Severity:    severity.Low,
+}
+
+// warnExpiringIgnores writes a warning for each ignore below path which applies to the current workspace and
+// expires within the given number of days. Ignores which have already expired are reported as results by
+// findExpiredIgnores.
+func warnExpiringIgnores(w io.Writer, path string, days int, now time.Time) error {
+	directives, err := ignores.Find(path)
+	if err != nil {
+		return fmt.Errorf("failed to find ignores: %w", err)
+	}
+	deadline := now.AddDate(0, 0, days)
+	for _, directive := range directives {
+		if !forWorkspace(directive) || directive.Expiry == nil || now.After(*directive.Expiry) {
+			continue
+		}
+		if directive.Expiry.Before(deadline) {
+			_, _ = fmt.Fprintf(w, "WARNING: The ignore for %s at %s:%d expires on %s.\n", directive.RuleID, directive.Filename, directive.Line, directive.Expiry.Format("2006-01-02"))
+		}
+	}
+	return nil
+}
+
+// findExpiredIgnores returns a failed result for each ignore below dir which applies to the current workspace
+// and has expired, at the line of the comment.
+func findExpiredIgnores(target fs.FS, dir string, now time.Time) (scan.Results, error) {
+	directives, err := ignores.FindFS(target, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find ignores: %w", err)
+	}
+	// the filenames are relative to dir, or are the name of the file if dir is a file
+	parent := dir
+	if info, err := fs.Stat(target, dir); err == nil && !info.IsDir() {
+		parent = path.Dir(dir)
+	}
+	var results scan.Results
+	for _, directive := range directives {
+		if !forWorkspace(directive) || directive.Expiry == nil || !now.After(*directive.Expiry) {
+			continue
+		}
+		filename := path.Join(parent, directive.Filename)
+		block := sourceBlock(target, hcl.Range{
+			Filename: filename,
+			Start:    hcl.Pos{Line: directive.Line},
+			End:      hcl.Pos{Line: directive.Line},
+		})
+		results.Add(fmt.Sprintf("The ignore for %s expired on %s and no longer applies.", directive.RuleID, directive.Expiry.Format("2006-01-02")), block)
+	}
+	return results, nil
+}
+
+func forWorkspace(directive ignores.Directive) bool {
+	return directive.Workspace == "" || directive.Workspace == workspace
+}
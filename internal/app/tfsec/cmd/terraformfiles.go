@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"io/fs"
+	"strings"
+
+	"github.com/aquasecurity/defsec/pkg/terraform"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// walkTerraformFiles calls fn with the path of each terraform file below dir, in lexical order. Directories
+// named .terraform, where terraform installs modules, are left out.
+func walkTerraformFiles(target fs.FS, dir string, fn func(filePath string) error) error {
+	return fs.WalkDir(target, dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".terraform" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !isTerraformFile(filePath) {
+			return nil
+		}
+		return fn(filePath)
+	})
+}
+
+// parseTerraformFile reads and parses the terraform file, as JSON if it is a .tf.json file and as HCL
+// otherwise. The file may be partially parsed when there are error diagnostics, and is nil if nothing could
+// be parsed.
+func parseTerraformFile(target fs.FS, filePath string) (*hcl.File, hcl.Diagnostics, error) {
+	data, err := fs.ReadFile(target, filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	parser := hclparse.NewParser()
+	if strings.HasSuffix(filePath, ".json") {
+		file, diags := parser.ParseJSON(data, filePath)
+		return file, diags, nil
+	}
+	file, diags := parser.ParseHCL(data, filePath)
+	return file, diags, nil
+}
+
+// sourceBlock returns a block covering the given range of a file, to report a result for source which is not a
+// block, or to make a range for such source, as the range type is internal to defsec.
+func sourceBlock(target fs.FS, rng hcl.Range) *terraform.Block {
+	return terraform.NewBlock(&hcl.Block{
+		Type: "terraform",
+		Body: &hclsyntax.Body{SrcRange: rng},
+	}, nil, nil, nil, "", target)
+}
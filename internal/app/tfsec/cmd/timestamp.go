@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aquasecurity/defsec/pkg/scanners/terraform/parser/funcs"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+var systemTimestampFunc = funcs.TimestampFunc
+
+// useFixedTimestamp makes timestamp() return the given RFC 3339 time during evaluation, so that scans of
+// configuration which uses it are reproducible. An empty value restores the system clock.
+func useFixedTimestamp(value string) error {
+	if value == "" {
+		funcs.TimestampFunc = systemTimestampFunc
+		return nil
+	}
+	now, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return fmt.Errorf("'%s' is not an RFC 3339 time, such as 2022-03-01T12:00:00Z", value)
+	}
+	funcs.TimestampFunc = function.New(&function.Spec{
+		Params: []function.Parameter{},
+		Type:   function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.StringVal(now.UTC().Format(time.RFC3339)), nil
+		},
+	})
+	return nil
+}
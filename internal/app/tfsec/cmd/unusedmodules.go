@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var moduleSourceSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "module", LabelNames: []string{"name"}},
+		{Type: "variable", LabelNames: []string{"name"}},
+		{Type: "locals"},
+	},
+}
+
+// findUnusedModules returns the directories of terraform files below dir which are never used as a module.
+// The root modules are the shallowest directories containing terraform files, along with the directories in
+// their examples directory, and any directory below a modules directory is expected to be used as a module.
+// A directory is used if it can be reached from a root module through the local sources of module blocks.
+func findUnusedModules(target fs.FS, dir string) ([]string, error) {
+	files := make(map[string][]string)
+	err := walkTerraformFiles(target, dir, func(filePath string) error {
+		files[path.Dir(filePath)] = append(files[path.Dir(filePath)], filePath)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make(map[string][]string)
+	for moduleDir, filePaths := range files {
+		localSources, err := findLocalModuleSources(target, moduleDir, filePaths)
+		if err != nil {
+			return nil, err
+		}
+		sources[moduleDir] = localSources
+	}
+
+	var queue []string
+	for moduleDir := range sources {
+		if isRootModuleDir(moduleDir, sources) {
+			queue = append(queue, moduleDir)
+		}
+	}
+	used := make(map[string]bool)
+	for len(queue) > 0 {
+		moduleDir := queue[0]
+		queue = queue[1:]
+		if used[moduleDir] {
+			continue
+		}
+		used[moduleDir] = true
+		queue = append(queue, sources[moduleDir]...)
+	}
+
+	var unused []string
+	for moduleDir := range sources {
+		if !used[moduleDir] {
+			unused = append(unused, moduleDir)
+		}
+	}
+	sort.Strings(unused)
+	return unused, nil
+}
+
+func isRootModuleDir(moduleDir string, sources map[string][]string) bool {
+	for _, part := range strings.Split(moduleDir, "/") {
+		if part == "modules" {
+			return false
+		}
+	}
+	for child, parent := moduleDir, path.Dir(moduleDir); child != parent; child, parent = parent, path.Dir(parent) {
+		if _, ok := sources[parent]; ok {
+			// nested below another module, which is only a root if it is one of the examples for that module
+			return path.Dir(moduleDir) == path.Join(parent, "examples")
+		}
+	}
+	return true
+}
+
+// findLocalModuleSources returns the directories of the modules called with a local source from the files of
+// the module in moduleDir. A source may refer to the locals of the module and the defaults of its variables.
+func findLocalModuleSources(target fs.FS, moduleDir string, filePaths []string) ([]string, error) {
+	var bodies []hcl.Body
+	for _, filePath := range filePaths {
+		file, _, err := parseTerraformFile(target, filePath)
+		if err != nil {
+			return nil, err
+		}
+		if file != nil {
+			bodies = append(bodies, file.Body)
+		}
+	}
+
+	ctx := moduleSourceContext(bodies)
+	var localSources []string
+	for _, body := range bodies {
+		content, _, _ := body.PartialContent(moduleSourceSchema)
+		for _, block := range content.Blocks {
+			if block.Type != "module" {
+				continue
+			}
+			attributes, _ := block.Body.JustAttributes()
+			attribute, ok := attributes["source"]
+			if !ok {
+				continue
+			}
+			source, diags := attribute.Expr.Value(ctx)
+			if diags.HasErrors() || !source.Type().Equals(cty.String) || !source.IsKnown() || source.IsNull() {
+				continue
+			}
+			if value := source.AsString(); strings.HasPrefix(value, "./") || strings.HasPrefix(value, "../") {
+				localSources = append(localSources, path.Join(moduleDir, value))
+			}
+		}
+	}
+	return localSources, nil
+}
+
+// moduleSourceContext returns the context for evaluating module sources, with the defaults of the variables and
+// the locals of the module. Locals which refer to other locals are evaluated once those are known.
+func moduleSourceContext(bodies []hcl.Body) *hcl.EvalContext {
+	variables := make(map[string]cty.Value)
+	pending := make(map[string]hcl.Expression)
+	for _, body := range bodies {
+		content, _, _ := body.PartialContent(moduleSourceSchema)
+		for _, block := range content.Blocks {
+			attributes, _ := block.Body.JustAttributes()
+			switch block.Type {
+			case "variable":
+				if attribute, ok := attributes["default"]; ok {
+					if value, diags := attribute.Expr.Value(nil); !diags.HasErrors() {
+						variables[block.Labels[0]] = value
+					}
+				}
+			case "locals":
+				for name, attribute := range attributes {
+					pending[name] = attribute.Expr
+				}
+			}
+		}
+	}
+
+	ctx := &hcl.EvalContext{Variables: map[string]cty.Value{"var": cty.ObjectVal(variables)}}
+	locals := make(map[string]cty.Value)
+	for evaluated := true; evaluated && len(pending) > 0; {
+		evaluated = false
+		ctx.Variables["local"] = cty.ObjectVal(locals)
+		for name, expr := range pending {
+			if value, diags := expr.Value(ctx); !diags.HasErrors() {
+				locals[name] = value
+				delete(pending, name)
+				evaluated = true
+			}
+		}
+	}
+	ctx.Variables["local"] = cty.ObjectVal(locals)
+	return ctx
+}
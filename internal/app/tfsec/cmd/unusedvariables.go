@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var variableSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{{Type: "variable", LabelNames: []string{"name"}}},
+}
+
+// jsonVariableReferencePattern finds references in the strings of JSON configuration, where expressions are
+// only templates and are not parsed until they are evaluated. References may be var.name or var["name"], with
+// the quotes escaped in the JSON.
+var jsonVariableReferencePattern = regexp.MustCompile(`\bvar(?:\.([A-Za-z_][A-Za-z0-9_-]*)|\[\\"([A-Za-z_][A-Za-z0-9_-]*)\\"\])`)
+
+type unusedVariable struct {
+	Filename string
+	Line     int
+	Name     string
+}
+
+// findUnusedVariables returns the variables declared in each module below dir which are not referenced by
+// any expression in the same module. References within variable blocks, such as in validation conditions,
+// do not count, as a variable can only refer to itself there.
+func findUnusedVariables(target fs.FS, dir string) ([]unusedVariable, error) {
+	declared := make(map[string][]unusedVariable)
+	referenced := make(map[string]map[string]bool)
+	err := walkTerraformFiles(target, dir, func(filePath string) error {
+		moduleDir := path.Dir(filePath)
+		if referenced[moduleDir] == nil {
+			referenced[moduleDir] = make(map[string]bool)
+		}
+		variables, err := findVariables(target, filePath, referenced[moduleDir])
+		if err != nil {
+			return err
+		}
+		declared[moduleDir] = append(declared[moduleDir], variables...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var unused []unusedVariable
+	for moduleDir, variables := range declared {
+		for _, variable := range variables {
+			if !referenced[moduleDir][variable.Name] {
+				unused = append(unused, variable)
+			}
+		}
+	}
+	sort.Slice(unused, func(i, j int) bool {
+		if unused[i].Filename != unused[j].Filename {
+			return unused[i].Filename < unused[j].Filename
+		}
+		return unused[i].Line < unused[j].Line
+	})
+	return unused, nil
+}
+
+// findVariables returns the variables declared in the given file, and adds the names of the variables it
+// references to referenced.
+func findVariables(target fs.FS, filePath string, referenced map[string]bool) ([]unusedVariable, error) {
+	file, _, err := parseTerraformFile(target, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, nil
+	}
+
+	content, _, _ := file.Body.PartialContent(variableSchema)
+	var variables []unusedVariable
+	for _, block := range content.Blocks {
+		variables = append(variables, unusedVariable{
+			Filename: filePath,
+			Line:     block.DefRange.Start.Line,
+			Name:     block.Labels[0],
+		})
+	}
+
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		// JSON configuration, where the references are only found in the text of its strings
+		for _, match := range jsonVariableReferencePattern.FindAllSubmatch(file.Bytes, -1) {
+			referenced[string(match[1])+string(match[2])] = true
+		}
+		return variables, nil
+	}
+	for _, block := range body.Blocks {
+		if block.Type != "variable" {
+			addVariableReferences(block.Body, referenced)
+		}
+	}
+	return variables, nil
+}
+
+func addVariableReferences(body *hclsyntax.Body, referenced map[string]bool) {
+	for _, attribute := range body.Attributes {
+		for _, traversal := range attribute.Expr.Variables() {
+			if traversal.RootName() != "var" || len(traversal) < 2 {
+				continue
+			}
+			switch step := traversal[1].(type) {
+			case hcl.TraverseAttr:
+				referenced[step.Name] = true
+			case hcl.TraverseIndex:
+				// var["name"] is the same reference as var.name
+				if !step.Key.IsNull() && step.Key.Type() == cty.String {
+					referenced[step.Key.AsString()] = true
+				}
+			}
+		}
+	}
+	for _, block := range body.Blocks {
+		addVariableReferences(block.Body, referenced)
+	}
+}
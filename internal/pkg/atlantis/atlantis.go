@@ -0,0 +1,55 @@
+package atlantis
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigFiles are the names atlantis reads its repo level configuration from, in order of preference.
+var ConfigFiles = []string{"atlantis.yaml", "atlantis.yml"}
+
+type Config struct {
+	Version  int       `yaml:"version"`
+	Projects []Project `yaml:"projects"`
+}
+
+// Project is a terraform root as configured for atlantis. Dir is relative to the directory of the
+// configuration file, and Workspace is empty if the project uses the default workspace.
+type Project struct {
+	Name      string `yaml:"name"`
+	Dir       string `yaml:"dir"`
+	Workspace string `yaml:"workspace"`
+}
+
+// FindConfig returns the path of the atlantis configuration file in dir.
+func FindConfig(dir string) (string, error) {
+	for _, name := range ConfigFiles {
+		configPath := filepath.Join(dir, name)
+		if _, err := os.Stat(configPath); err == nil {
+			return configPath, nil
+		}
+	}
+	return "", fmt.Errorf("no atlantis.yaml found in '%s'", dir)
+}
+
+func LoadConfig(configFilePath string) (*Config, error) {
+	content, err := ioutil.ReadFile(configFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read atlantis config '%s': %w", configFilePath, err)
+	}
+	var config Config
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("failed to load atlantis config '%s': %w", configFilePath, err)
+	}
+	for i, project := range config.Projects {
+		if project.Dir == "" {
+			return nil, fmt.Errorf("atlantis project %d in '%s' has no dir", i+1, configFilePath)
+		}
+		config.Projects[i].Dir = filepath.ToSlash(filepath.Clean(project.Dir))
+	}
+	return &config, nil
+}
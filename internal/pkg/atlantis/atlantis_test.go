@@ -0,0 +1,69 @@
+package atlantis_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aquasecurity/tfsec/internal/pkg/atlantis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectsFromConfig(t *testing.T) {
+	content := `
+version: 3
+automerge: true
+projects:
+- name: network-prod
+  dir: network
+  workspace: prod
+  autoplan:
+    when_modified: ["*.tf", "../modules/**/*.tf"]
+- name: network-staging
+  dir: network
+  workspace: staging
+- dir: ./apps/web/
+`
+	dir := writeConfig(t, "atlantis.yaml", content)
+
+	configPath, err := atlantis.FindConfig(dir)
+	require.NoError(t, err)
+	c, err := atlantis.LoadConfig(configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, c.Version)
+	assert.Equal(t, []atlantis.Project{
+		{Name: "network-prod", Dir: "network", Workspace: "prod"},
+		{Name: "network-staging", Dir: "network", Workspace: "staging"},
+		{Dir: "apps/web"},
+	}, c.Projects)
+}
+
+func TestProjectWithoutDir(t *testing.T) {
+	content := `
+version: 3
+projects:
+- name: network
+`
+	dir := writeConfig(t, "atlantis.yml", content)
+
+	configPath, err := atlantis.FindConfig(dir)
+	require.NoError(t, err)
+	_, err = atlantis.LoadConfig(configPath)
+	assert.Error(t, err)
+}
+
+func TestMissingConfig(t *testing.T) {
+	_, err := atlantis.FindConfig(t.TempDir())
+	assert.Error(t, err)
+}
+
+func writeConfig(t *testing.T, filename, content string) string {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, filename), []byte(content), os.ModePerm)
+	require.NoError(t, err)
+
+	return dir
+}
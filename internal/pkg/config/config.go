@@ -18,6 +18,9 @@ type Config struct {
 	ExcludedChecks         []string          `json:"exclude,omitempty" yaml:"exclude,omitempty"`
 	IncludedChecks         []string          `json:"include,omitempty" yaml:"include,omitempty"`
 	MinimumRequiredVersion string            `json:"min_required_version" yaml:"min_required_version,omitempty"`
+	TfvarsFiles            []string          `json:"tfvars_files,omitempty" yaml:"tfvars_files,omitempty"`
+	ExcludePaths           []string          `json:"exclude_paths,omitempty" yaml:"exclude_paths,omitempty"`
+	NoModuleDownloads      bool              `json:"no_module_downloads,omitempty" yaml:"no_module_downloads,omitempty"`
 }
 
 func LoadConfig(configFilePath string) (*Config, error) {
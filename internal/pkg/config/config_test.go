@@ -91,6 +91,35 @@ func TestWarningIsRewrittenAsMedium(t *testing.T) {
 	assert.Equal(t, "MEDIUM", sev)
 }
 
+func TestLoaderOptionsFromYAML(t *testing.T) {
+	content := `
+tfvars_files:
+  - env/prod/*.tfvars
+exclude_paths:
+  - modules/vendored
+no_module_downloads: true
+`
+	c := load(t, "config.yaml", content)
+
+	assert.Equal(t, []string{"env/prod/*.tfvars"}, c.TfvarsFiles)
+	assert.Equal(t, []string{"modules/vendored"}, c.ExcludePaths)
+	assert.True(t, c.NoModuleDownloads)
+}
+
+func TestLoaderOptionsFromJSON(t *testing.T) {
+	content := `{
+  "tfvars_files": ["env/prod/*.tfvars"],
+  "exclude_paths": ["modules/vendored"],
+  "no_module_downloads": true
+}
+`
+	c := load(t, "config.json", content)
+
+	assert.Equal(t, []string{"env/prod/*.tfvars"}, c.TfvarsFiles)
+	assert.Equal(t, []string{"modules/vendored"}, c.ExcludePaths)
+	assert.True(t, c.NoModuleDownloads)
+}
+
 func load(t *testing.T, filename, content string) *config.Config {
 	dir, err := ioutil.TempDir("", "")
 	require.NoError(t, err)
@@ -23,6 +23,7 @@ var ValidCheckActions = []CheckAction{
 	GreaterThan,
 	GreaterThanOrEqualTo,
 	RegexMatches,
+	RawRegexMatches,
 	RequiresPresence,
 	IsAny,
 	IsNone,
@@ -66,6 +67,9 @@ const NotEqual CheckAction = "notEqual"
 // RegexMatches checks that the named attribute has a value that matches the regex
 const RegexMatches CheckAction = "regexMatches"
 
+// RawRegexMatches checks that the unevaluated source of the named attribute matches the regex
+const RawRegexMatches CheckAction = "rawRegexMatches"
+
 // IsAny checks that the named attribute value can be found in the provided slice
 const IsAny CheckAction = "isAny"
 
@@ -0,0 +1,1776 @@
+package custom
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aquasecurity/defsec/pkg/scanners/terraform/parser"
+	"github.com/aquasecurity/defsec/pkg/terraform"
+	"github.com/liamg/memoryfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type evaluationTest struct {
+	name      string
+	source    string
+	files     map[string]string
+	matchSpec MatchSpec
+	expected  bool
+}
+
+func runEvaluationTests(t *testing.T, tests []evaluationTest) {
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			module := parseFromFiles(t, test.source, test.files)[0]
+			block := module.GetResourcesByType("aws_s3_bucket")[0]
+			result := evalMatchSpec(block, &test.matchSpec, NewCustomContext(module))
+			assert.Equal(t, test.expected, result, "custom check saw an unexpected evaluated value.")
+		})
+	}
+}
+
+func parseFromFiles(t *testing.T, source string, files map[string]string) terraform.Modules {
+	f := createTestFile(t, "test.tf", source).(*memoryfs.FS)
+	for filename, contents := range files {
+		require.NoError(t, f.MkdirAll(filepath.Dir(filename), 0o700))
+		require.NoError(t, f.WriteFile(filename, []byte(contents), 0o600))
+	}
+	p := parser.New(f, "", parser.OptionStopOnHCLError(true))
+	require.NoError(t, p.ParseFS(context.TODO(), "."))
+	modules, _, err := p.EvaluateAll(context.TODO())
+	require.NoError(t, err)
+	return modules
+}
+
+func TestPathFunctions(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	runEvaluationTests(t, []evaluationTest{
+		{
+			name: "check `pathexpand` expands the home directory",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = pathexpand("~/modules/x")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: filepath.Join(home, "modules", "x"),
+			},
+			expected: true,
+		},
+		{
+			name: "check `pathexpand` leaves paths without a tilde untouched",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = pathexpand("/etc/modules/x")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: "/etc/modules/x",
+			},
+			expected: true,
+		},
+		{
+			name: "check `abspath` resolves relative to the working directory",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = abspath("modules/x")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: filepath.ToSlash(filepath.Join(cwd, "modules", "x")),
+			},
+			expected: true,
+		},
+		{
+			name: "check `abspath` of `path.module` is absolute",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = abspath(path.module)
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "startsWith",
+				MatchValue: fmt.Sprintf("%c", filepath.Separator),
+			},
+			expected: true,
+		},
+	})
+}
+
+func TestCoalesceFunctions(t *testing.T) {
+	runEvaluationTests(t, []evaluationTest{
+		{
+			name: "check `coalesce` skips nulls and empty strings",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = coalesce(null, "", "fallback")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: "fallback",
+			},
+			expected: true,
+		},
+		{
+			name: "check `coalesce` resolves variable arguments",
+			source: `
+variable "name" {
+  default = "configured"
+}
+
+resource "aws_s3_bucket" "default" {
+  bucket = coalesce("", var.name, "default")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: "configured",
+			},
+			expected: true,
+		},
+		{
+			name: "check `coalesce` converts mixed string and number arguments to a string",
+			source: `
+resource "aws_s3_bucket" "default" {
+  port = coalesce(null, 8080, "80")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "port",
+				Action:     "equals",
+				MatchValue: "8080",
+			},
+			expected: true,
+		},
+		{
+			name: "check `coalesce` converts mixed bool and string arguments to a string",
+			source: `
+resource "aws_s3_bucket" "default" {
+  enabled = coalesce("", true)
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "enabled",
+				Action:     "equals",
+				MatchValue: "true",
+			},
+			expected: true,
+		},
+		{
+			name: "check `coalescelist` skips empty lists",
+			source: `
+resource "aws_s3_bucket" "default" {
+  names = coalescelist([], ["first", "second"])
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "names",
+				Action:     "contains",
+				MatchValue: "second",
+			},
+			expected: true,
+		},
+		{
+			name: "check `compact` keeps non-empty strings",
+			source: `
+resource "aws_s3_bucket" "default" {
+  names = compact(["first", "", "second"])
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "names",
+				Action:     "contains",
+				MatchValue: "first",
+			},
+			expected: true,
+		},
+		{
+			name: "check `compact` drops empty strings",
+			source: `
+resource "aws_s3_bucket" "default" {
+  names = compact(["first", "", "second"])
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "names",
+				Action:     "contains",
+				MatchValue: "",
+			},
+			expected: false,
+		},
+	})
+}
+
+func TestMapFunctions(t *testing.T) {
+	runEvaluationTests(t, []evaluationTest{
+		{
+			name: "check `merge` combines common tags with resource tags for `hasTag`",
+			source: `
+variable "common_tags" {
+  default = {
+    Owner = "platform"
+  }
+}
+
+resource "aws_s3_bucket" "default" {
+  tags = merge(var.common_tags, {
+    Name = "logs"
+  })
+}
+`,
+			matchSpec: MatchSpec{
+				Action: "and",
+				PredicateMatchSpec: []MatchSpec{
+					{
+						Action:     "hasTag",
+						MatchValue: "Owner",
+					},
+					{
+						Action:     "hasTag",
+						MatchValue: "Name",
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "check `merge` lets later maps override earlier ones",
+			source: `
+locals {
+  defaults = {
+    acl = "public-read"
+  }
+}
+
+resource "aws_s3_bucket" "default" {
+  acl = merge(local.defaults, { acl = "private" }).acl
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "acl",
+				Action:     "equals",
+				MatchValue: "private",
+			},
+			expected: true,
+		},
+		{
+			name: "check `lookup` returns the value of an existing key",
+			source: `
+variable "acls" {
+  default = {
+    logs = "log-delivery-write"
+  }
+}
+
+resource "aws_s3_bucket" "default" {
+  acl = lookup(var.acls, "logs", "private")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "acl",
+				Action:     "equals",
+				MatchValue: "log-delivery-write",
+			},
+			expected: true,
+		},
+		{
+			name: "check `lookup` returns the default for a missing key",
+			source: `
+variable "acls" {
+  default = {
+    logs = "log-delivery-write"
+  }
+}
+
+resource "aws_s3_bucket" "default" {
+  acl = lookup(var.acls, "data", "private")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "acl",
+				Action:     "equals",
+				MatchValue: "private",
+			},
+			expected: true,
+		},
+		{
+			name: "check `keys` returns the map keys",
+			source: `
+resource "aws_s3_bucket" "default" {
+  names = keys({ first = 1, second = 2 })
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "names",
+				Action:     "contains",
+				MatchValue: "second",
+			},
+			expected: true,
+		},
+		{
+			name: "check `values` returns the map values",
+			source: `
+resource "aws_s3_bucket" "default" {
+  names = values({ first = "a", second = "b" })
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "names",
+				Action:     "contains",
+				MatchValue: "b",
+			},
+			expected: true,
+		},
+	})
+}
+
+func TestListFunctions(t *testing.T) {
+	runEvaluationTests(t, []evaluationTest{
+		{
+			name: "check `slice` returns the requested range",
+			source: `
+resource "aws_s3_bucket" "default" {
+  cidr_blocks = slice(["10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24"], 1, 2)
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "cidr_blocks",
+				Action:     "contains",
+				MatchValue: "10.0.1.0/24",
+			},
+			expected: true,
+		},
+		{
+			name: "check `slice` excludes the end index",
+			source: `
+resource "aws_s3_bucket" "default" {
+  cidr_blocks = slice(["10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24"], 1, 2)
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "cidr_blocks",
+				Action:     "contains",
+				MatchValue: "10.0.2.0/24",
+			},
+			expected: false,
+		},
+		{
+			name: "check `concat` joins lists",
+			source: `
+variable "private" {
+  default = ["10.0.0.0/24"]
+}
+
+resource "aws_s3_bucket" "default" {
+  cidr_blocks = concat(var.private, ["0.0.0.0/0"])
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "cidr_blocks",
+				Action:     "contains",
+				MatchValue: "0.0.0.0/0",
+			},
+			expected: true,
+		},
+		{
+			name: "check `element` returns the indexed item",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = element(["a", "b", "c"], 1)
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: "b",
+			},
+			expected: true,
+		},
+		{
+			name: "check `element` wraps out of range indexes",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = element(["a", "b", "c"], 4)
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: "b",
+			},
+			expected: true,
+		},
+		{
+			name: "check `length` counts list items",
+			source: `
+resource "aws_s3_bucket" "default" {
+  count_of = length(["a", "b", "c"])
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "count_of",
+				Action:     "equals",
+				MatchValue: 3,
+			},
+			expected: true,
+		},
+		{
+			name: "check `flatten` flattens nested lists",
+			source: `
+resource "aws_s3_bucket" "default" {
+  cidr_blocks = flatten([["10.0.0.0/24"], [["0.0.0.0/0"]], []])
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "cidr_blocks",
+				Action:     "contains",
+				MatchValue: "0.0.0.0/0",
+			},
+			expected: true,
+		},
+	})
+}
+
+func TestCidrFunctions(t *testing.T) {
+	runEvaluationTests(t, []evaluationTest{
+		{
+			name: "check `cidrsubnet` computes the subnet for an index",
+			source: `
+variable "vpc_cidr" {
+  default = "10.0.0.0/16"
+}
+
+resource "aws_s3_bucket" "default" {
+  cidr_block = cidrsubnet(var.vpc_cidr, 8, 2)
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "cidr_block",
+				Action:     "equals",
+				MatchValue: "10.0.2.0/24",
+			},
+			expected: true,
+		},
+		{
+			name: "check `cidrsubnets` computes consecutive subnets",
+			source: `
+resource "aws_s3_bucket" "default" {
+  cidr_blocks = cidrsubnets("10.0.0.0/16", 8, 8)
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "cidr_blocks",
+				Action:     "contains",
+				MatchValue: "10.0.1.0/24",
+			},
+			expected: true,
+		},
+		{
+			name: "check `cidrsubnets` allocates subnets of different sizes without overlap",
+			source: `
+locals {
+  subnets = cidrsubnets("10.0.0.0/16", 4, 8, 4)
+}
+
+resource "aws_s3_bucket" "default" {
+  cidr_block = local.subnets[2]
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "cidr_block",
+				Action:     "equals",
+				MatchValue: "10.0.32.0/20",
+			},
+			expected: true,
+		},
+		{
+			name: "check `cidrsubnets` results can be indexed with `count.index`",
+			source: `
+resource "aws_s3_bucket" "default" {
+  count      = 2
+  cidr_block = cidrsubnets("10.0.0.0/16", 8, 8)[count.index]
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "cidr_block",
+				Action:     "equals",
+				MatchValue: "10.0.0.0/24",
+			},
+			expected: true,
+		},
+		{
+			name: "check `cidrhost` computes the host address",
+			source: `
+resource "aws_s3_bucket" "default" {
+  host = cidrhost("10.0.2.0/24", 5)
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "host",
+				Action:     "equals",
+				MatchValue: "10.0.2.5",
+			},
+			expected: true,
+		},
+		{
+			name: "check `cidrnetmask` computes the netmask",
+			source: `
+resource "aws_s3_bucket" "default" {
+  netmask = cidrnetmask("10.0.0.0/12")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "netmask",
+				Action:     "equals",
+				MatchValue: "255.240.0.0",
+			},
+			expected: true,
+		},
+	})
+}
+
+func TestComputedSubnetTriggersOpenIngressCheck(t *testing.T) {
+	var tests = []struct {
+		name     string
+		vpcCidr  string
+		expected int
+	}{
+		{
+			name:     "check a computed subnet which is open to the world is reported",
+			vpcCidr:  "0.0.0.0/0",
+			expected: 2,
+		},
+		{
+			name:     "check computed private subnets are not reported",
+			vpcCidr:  "10.0.0.0/16",
+			expected: 0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := scanTerraform(t, fmt.Sprintf(`
+variable "vpc_cidr" {
+  default = "%s"
+}
+
+resource "aws_security_group_rule" "ingress" {
+  count       = 2
+  type        = "ingress"
+  description = "ingress"
+  cidr_blocks = [cidrsubnet(var.vpc_cidr, 0, 0), cidrsubnet("10.1.0.0/16", 8, count.index)]
+}
+`, test.vpcCidr))
+			var found int
+			for _, result := range results.GetFailed() {
+				if result.Rule().LongID() == "aws-vpc-no-public-ingress-sgr" {
+					found++
+				}
+			}
+			assert.Equal(t, test.expected, found, "computed subnet was not checked correctly for each instance.")
+		})
+	}
+}
+
+func TestStringFunctions(t *testing.T) {
+	runEvaluationTests(t, []evaluationTest{
+		{
+			name: "check `format` builds a name which satisfies the naming policy",
+			source: `
+variable "environment" {
+  default = "prod"
+}
+
+resource "aws_s3_bucket" "default" {
+  bucket = format("%s-%s-logs", "acme", var.environment)
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "regexMatches",
+				MatchValue: "^acme-(dev|prod)-[a-z]+$",
+			},
+			expected: true,
+		},
+		{
+			name: "check `format` builds a name which breaks the naming policy",
+			source: `
+variable "environment" {
+  default = "Staging"
+}
+
+resource "aws_s3_bucket" "default" {
+  bucket = format("%s-%s-logs", "acme", var.environment)
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "regexMatches",
+				MatchValue: "^acme-(dev|prod)-[a-z]+$",
+			},
+			expected: false,
+		},
+		{
+			name: "check `formatlist` formats each item",
+			source: `
+resource "aws_s3_bucket" "default" {
+  names = formatlist("acme-%s", ["logs", "data"])
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "names",
+				Action:     "contains",
+				MatchValue: "acme-data",
+			},
+			expected: true,
+		},
+		{
+			name: "check `join` joins list items",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = join("-", ["acme", "prod", "logs"])
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: "acme-prod-logs",
+			},
+			expected: true,
+		},
+		{
+			name: "check `split` splits a string",
+			source: `
+resource "aws_s3_bucket" "default" {
+  names = split(",", "logs,data")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "names",
+				Action:     "contains",
+				MatchValue: "data",
+			},
+			expected: true,
+		},
+		{
+			name: "check `replace` replaces substrings",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = replace("acme_prod_logs", "_", "-")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: "acme-prod-logs",
+			},
+			expected: true,
+		},
+		{
+			name: "check `lower` and `upper` change case",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = "${lower("ACME")}-${upper("prod")}"
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: "acme-PROD",
+			},
+			expected: true,
+		},
+	})
+}
+
+// regex, regexall and replace match with Go's regexp package, which is the RE2 syntax terraform uses too.
+func TestRegexFunctions(t *testing.T) {
+	runEvaluationTests(t, []evaluationTest{
+		{
+			name: "check `regex` extracts a named capture group",
+			source: `
+locals {
+  parts = regex("^(?P<team>[a-z]+)-(?P<env>[a-z]+)-", "acme-prod-logs")
+}
+
+resource "aws_s3_bucket" "default" {
+  bucket = local.parts.env
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: "prod",
+			},
+			expected: true,
+		},
+		{
+			name: "check `regex` extracts unnamed capture groups by index",
+			source: `
+locals {
+  parts = regex("^([a-z]+)-([a-z]+)-", "acme-prod-logs")
+}
+
+resource "aws_s3_bucket" "default" {
+  bucket = local.parts[1]
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: "prod",
+			},
+			expected: true,
+		},
+		{
+			name: "check `regexall` finds every match",
+			source: `
+resource "aws_s3_bucket" "default" {
+  count_of_envs = length(regexall("(dev|prod)", "dev-prod-logs"))
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "count_of_envs",
+				Action:     "equals",
+				MatchValue: 2,
+			},
+			expected: true,
+		},
+		{
+			name: "check `replace` with a regular expression substitutes capture groups",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = replace("acme_prod_logs", "/^([a-z]+)_([a-z]+)_/", "$2-$1-")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: "prod-acme-logs",
+			},
+			expected: true,
+		},
+	})
+}
+
+// timestamp reads the system clock when the module is evaluated, so only its format is checked here.
+func TestTimeFunctions(t *testing.T) {
+	runEvaluationTests(t, []evaluationTest{
+		{
+			name: "check `timeadd` adds a duration to a fixed time",
+			source: `
+locals {
+  created = "2022-03-01T12:00:00Z"
+}
+
+resource "aws_s3_bucket" "default" {
+  expires = timeadd(local.created, "720h")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "expires",
+				Action:     "equals",
+				MatchValue: "2022-03-31T12:00:00Z",
+			},
+			expected: true,
+		},
+		{
+			name: "check `formatdate` formats a time",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = "backup-${formatdate("YYYY-MM-DD", timeadd("2022-12-31T23:00:00Z", "1h"))}"
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: "backup-2023-01-01",
+			},
+			expected: true,
+		},
+		{
+			name: "check `timestamp` returns an RFC 3339 time",
+			source: `
+resource "aws_s3_bucket" "default" {
+  created = timestamp()
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "created",
+				Action:     "regexMatches",
+				MatchValue: `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z$`,
+			},
+			expected: true,
+		},
+	})
+}
+
+func TestFileFunctions(t *testing.T) {
+	files := map[string]string{
+		"policies/bucket.json": `{"Statement":[{"Effect":"Allow","Principal":"*"}]}`,
+		"policies/other.json":  `{}`,
+	}
+	runEvaluationTests(t, []evaluationTest{
+		{
+			name: "check `file` reads a file relative to the module path",
+			source: `
+resource "aws_s3_bucket" "default" {
+  policy = file("${path.module}/policies/bucket.json")
+}
+`,
+			files: files,
+			matchSpec: MatchSpec{
+				Name:       "policy",
+				Action:     "regexMatches",
+				MatchValue: `"Principal":"\*"`,
+			},
+			expected: true,
+		},
+		{
+			name: "check `file` of a missing file leaves the attribute unresolved",
+			source: `
+resource "aws_s3_bucket" "default" {
+  policy = file("${path.module}/policies/missing.json")
+}
+`,
+			files: files,
+			matchSpec: MatchSpec{
+				Name:       "policy",
+				Action:     "regexMatches",
+				MatchValue: "Principal",
+			},
+			expected: false,
+		},
+		{
+			name: "check `filebase64` encodes the file contents",
+			source: `
+resource "aws_s3_bucket" "default" {
+  policy = filebase64("${path.module}/policies/other.json")
+}
+`,
+			files: files,
+			matchSpec: MatchSpec{
+				Name:       "policy",
+				Action:     "equals",
+				MatchValue: "e30=",
+			},
+			expected: true,
+		},
+	})
+}
+
+func TestTypeConversionFunctions(t *testing.T) {
+	runEvaluationTests(t, []evaluationTest{
+		{
+			name: "check `tolist` converts a set",
+			source: `
+resource "aws_s3_bucket" "default" {
+  names = tolist(toset(["logs", "data", "logs"]))
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "names",
+				Action:     "contains",
+				MatchValue: "data",
+			},
+			expected: true,
+		},
+		{
+			name: "check `tomap` converts an object",
+			source: `
+resource "aws_s3_bucket" "default" {
+  tags = tomap({ Owner = "platform" })
+}
+`,
+			matchSpec: MatchSpec{
+				Action:     "hasTag",
+				MatchValue: "Owner",
+			},
+			expected: true,
+		},
+		{
+			name: "check `tonumber` converts a string",
+			source: `
+resource "aws_s3_bucket" "default" {
+  retention = tonumber("30")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "retention",
+				Action:     "equals",
+				MatchValue: 30,
+			},
+			expected: true,
+		},
+		{
+			name: "check `tostring` converts a number",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = tostring(30)
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: "30",
+			},
+			expected: true,
+		},
+		{
+			name: "check `tobool` converts a string",
+			source: `
+resource "aws_s3_bucket" "default" {
+  force_destroy = tobool("true")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "force_destroy",
+				Action:     "equals",
+				MatchValue: true,
+			},
+			expected: true,
+		},
+	})
+}
+
+func TestForEachOverToset(t *testing.T) {
+	modules := parseFromSource(t, `
+variable "names" {
+  default = ["logs", "data", "logs"]
+}
+
+resource "aws_s3_bucket" "default" {
+  for_each = toset(var.names)
+  bucket   = each.value
+}
+`)
+	var buckets []string
+	for _, block := range modules[0].GetResourcesByType("aws_s3_bucket") {
+		buckets = append(buckets, block.GetAttribute("bucket").Value().AsString())
+	}
+	assert.ElementsMatch(t, []string{"logs", "data"}, buckets, "`for_each` over `toset` should expand one block per distinct item.")
+}
+
+func TestEncodingAndHashFunctions(t *testing.T) {
+	runEvaluationTests(t, []evaluationTest{
+		{
+			name: "check `base64encode` encodes a string",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = base64encode("logs")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: "bG9ncw==",
+			},
+			expected: true,
+		},
+		{
+			name: "check `base64decode` decodes a string",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = base64decode("bG9ncw==")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: "logs",
+			},
+			expected: true,
+		},
+		{
+			name: "check `base64gzip` compresses and encodes a string",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = base64gzip("logs")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "startsWith",
+				MatchValue: "H4sI",
+			},
+			expected: true,
+		},
+		{
+			name: "check `md5` hashes a string",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = md5("logs")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: "2165e4fa5bddb65a31f6a0c495c2fa37",
+			},
+			expected: true,
+		},
+		{
+			name: "check `sha1` hashes a string",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = sha1("logs")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: "474c797713f37901928aafb6adbae0241d1750bd",
+			},
+			expected: true,
+		},
+		{
+			name: "check `sha256` hashes a string",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = sha256("logs")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: "98f38f12db221a8cf8ca7aadfdcd759b01d52eb4ebb3eedbb2d97e92805c6960",
+			},
+			expected: true,
+		},
+		{
+			name: "check `sha512` hashes a string",
+			source: `
+resource "aws_s3_bucket" "default" {
+  bucket = sha512("logs")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: "48def738e2d0a1101c1a1d96279a624d68ac73a2ffc8adc528db5612d2e8760ecba148e48199a7db4a7751815f07806255af1c13b2b5a337ee8857d3d6d3e91e",
+			},
+			expected: true,
+		},
+	})
+}
+
+func TestDecodedUserDataIsScannedForSecrets(t *testing.T) {
+	results := scanTerraform(t, `
+locals {
+  encoded_user_data = "ZXhwb3J0IEFXU19BQ0NFU1NfS0VZX0lEPUFLSUFJT1NGT0ROTjdFWEFNUExFCg=="
+}
+
+resource "aws_instance" "default" {
+  ami           = "ami-12345667"
+  instance_type = "t2.small"
+  user_data     = base64decode(local.encoded_user_data)
+}
+`)
+	var found bool
+	for _, result := range results.GetFailed() {
+		if result.Rule().LongID() == "aws-ec2-no-secrets-in-user-data" {
+			found = true
+		}
+	}
+	assert.True(t, found, "secrets in decoded user data should be reported.")
+}
+
+func TestIterationSymbols(t *testing.T) {
+	namingPolicy := MatchSpec{
+		Name:       "bucket",
+		Action:     "regexMatches",
+		MatchValue: "^acme-(logs|data|[0-9])-bucket$",
+	}
+	var tests = []struct {
+		name     string
+		source   string
+		files    map[string]string
+		expected map[string]bool
+	}{
+		{
+			name: "check `each.key` and `each.value` resolve per resource instance",
+			source: `
+resource "aws_s3_bucket" "default" {
+  for_each = {
+    logs = "bucket"
+    Data = "bucket"
+  }
+  bucket = "acme-${each.key}-${each.value}"
+}
+`,
+			expected: map[string]bool{
+				"acme-logs-bucket": true,
+				"acme-Data-bucket": false,
+			},
+		},
+		{
+			name: "check `count.index` resolves per resource instance",
+			source: `
+resource "aws_s3_bucket" "default" {
+  count  = 2
+  bucket = "acme-${count.index}-bucket"
+}
+`,
+			expected: map[string]bool{
+				"acme-0-bucket": true,
+				"acme-1-bucket": true,
+			},
+		},
+		{
+			name: "check `each.key` resolves per module instance",
+			source: `
+module "buckets" {
+  for_each = toset(["logs", "Data"])
+  source   = "./modules/bucket"
+  name     = each.key
+}
+`,
+			files: map[string]string{
+				"modules/bucket/main.tf": `
+variable "name" {}
+
+resource "aws_s3_bucket" "default" {
+  bucket = "acme-${var.name}-bucket"
+}
+`,
+			},
+			expected: map[string]bool{
+				"acme-logs-bucket": true,
+				"acme-Data-bucket": false,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := make(map[string]bool)
+			for _, module := range parseFromFiles(t, test.source, test.files) {
+				for _, block := range module.GetResourcesByType("aws_s3_bucket") {
+					name := block.GetAttribute("bucket").Value().AsString()
+					results[name] = evalMatchSpec(block, &namingPolicy, NewCustomContext(module))
+				}
+			}
+			assert.Equal(t, test.expected, results, "iteration symbols were not resolved for each instance.")
+		})
+	}
+}
+
+func TestCollectionConstructionFunctions(t *testing.T) {
+	runEvaluationTests(t, []evaluationTest{
+		{
+			name: "check `zipmap` builds a map from keys and values",
+			source: `
+resource "aws_s3_bucket" "default" {
+  tags = zipmap(["Owner", "Environment"], ["platform", "prod"])
+}
+`,
+			matchSpec: MatchSpec{
+				Action:     "hasTag",
+				MatchValue: "Environment",
+			},
+			expected: true,
+		},
+		{
+			name: "check `setproduct` combines every element",
+			source: `
+resource "aws_s3_bucket" "default" {
+  names = [for pair in setproduct(["logs", "data"], ["dev", "prod"]) : join("-", pair)]
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "names",
+				Action:     "contains",
+				MatchValue: "data-prod",
+			},
+			expected: true,
+		},
+		{
+			name: "check `range` builds a sequence",
+			source: `
+resource "aws_s3_bucket" "default" {
+  names = [for i in range(1, 4) : "bucket-${i}"]
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "names",
+				Action:     "contains",
+				MatchValue: "bucket-3",
+			},
+			expected: true,
+		},
+	})
+}
+
+func TestForEachOverZipmap(t *testing.T) {
+	modules := parseFromSource(t, `
+variable "names" {
+  default = ["logs", "data"]
+}
+
+variable "acls" {
+  default = ["log-delivery-write", "private"]
+}
+
+resource "aws_s3_bucket" "default" {
+  for_each = zipmap(var.names, var.acls)
+  bucket   = each.key
+  acl      = each.value
+}
+`)
+	buckets := make(map[string]string)
+	for _, block := range modules[0].GetResourcesByType("aws_s3_bucket") {
+		buckets[block.GetAttribute("bucket").Value().AsString()] = block.GetAttribute("acl").Value().AsString()
+	}
+	assert.Equal(t, map[string]string{
+		"logs": "log-delivery-write",
+		"data": "private",
+	}, buckets, "`for_each` over `zipmap` should expand one block per key.")
+}
+
+func TestHeredocVariableDefaults(t *testing.T) {
+	runEvaluationTests(t, []evaluationTest{
+		{
+			name: "check a `<<` heredoc default keeps its indentation and trailing newline",
+			source: `
+variable "script" {
+  default = <<EOT
+#!/bin/bash
+  echo hello
+EOT
+}
+
+resource "aws_s3_bucket" "default" {
+  script = var.script
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "script",
+				Action:     "equals",
+				MatchValue: "#!/bin/bash\n  echo hello\n",
+			},
+			expected: true,
+		},
+		{
+			name: "check a `<<-` heredoc default has its common indentation removed",
+			source: `
+variable "script" {
+  default = <<-EOT
+    #!/bin/bash
+      echo hello
+    EOT
+}
+
+resource "aws_s3_bucket" "default" {
+  script = var.script
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "script",
+				Action:     "equals",
+				MatchValue: "#!/bin/bash\n  echo hello\n",
+			},
+			expected: true,
+		},
+	})
+}
+
+func TestHeredocPolicyDefaultIsChecked(t *testing.T) {
+	results := scanTerraform(t, `
+variable "policy" {
+  default = <<-EOT
+    {
+      "Version": "2012-10-17",
+      "Statement": [
+        {
+          "Effect": "Allow",
+          "Action": "s3:*",
+          "Resource": "*"
+        }
+      ]
+    }
+    EOT
+}
+
+resource "aws_iam_policy" "default" {
+  name   = "default"
+  policy = var.policy
+}
+`)
+	var found bool
+	for _, result := range results.GetFailed() {
+		if result.Rule().LongID() == "aws-iam-no-policy-wildcards" {
+			found = true
+		}
+	}
+	assert.True(t, found, "wildcards in a policy from a heredoc default should be reported.")
+}
+
+func TestMembershipAndSetFunctions(t *testing.T) {
+	runEvaluationTests(t, []evaluationTest{
+		{
+			name: "check `contains` finds a port in a variable",
+			source: `
+variable "allowed_ports" {
+  default = [22, 443]
+}
+
+resource "aws_s3_bucket" "default" {
+  ssh_allowed = contains(var.allowed_ports, 22)
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "ssh_allowed",
+				Action:     "equals",
+				MatchValue: true,
+			},
+			expected: true,
+		},
+		{
+			name: "check `distinct` removes duplicates",
+			source: `
+resource "aws_s3_bucket" "default" {
+  count_of = length(distinct(["a", "b", "a"]))
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "count_of",
+				Action:     "equals",
+				MatchValue: 2,
+			},
+			expected: true,
+		},
+		{
+			name: "check `index` finds the position of an element",
+			source: `
+resource "aws_s3_bucket" "default" {
+  position = index(["logs", "data"], "data")
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "position",
+				Action:     "equals",
+				MatchValue: 1,
+			},
+			expected: true,
+		},
+		{
+			name: "check `setintersection` keeps common elements",
+			source: `
+resource "aws_s3_bucket" "default" {
+  names = setintersection(["logs", "data", "backup"], ["data", "audit"])
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "names",
+				Action:     "contains",
+				MatchValue: "data",
+			},
+			expected: true,
+		},
+		{
+			name: "check `setunion` combines sets",
+			source: `
+resource "aws_s3_bucket" "default" {
+  names = setunion(["logs"], ["audit"])
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "names",
+				Action:     "contains",
+				MatchValue: "audit",
+			},
+			expected: true,
+		},
+		{
+			name: "check `contains` finds a number in a set of numbers",
+			source: `
+resource "aws_s3_bucket" "default" {
+  ports = toset([22, 443])
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "ports",
+				Action:     "contains",
+				MatchValue: 22,
+			},
+			expected: true,
+		},
+		{
+			name: "check `contains` does not find a missing number in a set of numbers",
+			source: `
+resource "aws_s3_bucket" "default" {
+  ports = toset([22, 443])
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "ports",
+				Action:     "contains",
+				MatchValue: 80,
+			},
+			expected: false,
+		},
+		{
+			name: "check `contains` finds a bool in a set of bools",
+			source: `
+resource "aws_s3_bucket" "default" {
+  flags = toset([true])
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "flags",
+				Action:     "contains",
+				MatchValue: true,
+			},
+			expected: true,
+		},
+		{
+			name: "check `setsubtract` removes elements",
+			source: `
+resource "aws_s3_bucket" "default" {
+  names = setsubtract(["logs", "data"], ["logs"])
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "names",
+				Action:     "contains",
+				MatchValue: "logs",
+			},
+			expected: false,
+		},
+	})
+}
+
+func TestContainsDecidesWhetherIngressIsCreated(t *testing.T) {
+	var tests = []struct {
+		name         string
+		allowedPorts string
+		expected     int
+	}{
+		{
+			name:         "check ingress created when `contains` finds the port is reported",
+			allowedPorts: "[22, 443]",
+			expected:     1,
+		},
+		{
+			name:         "check ingress skipped when `contains` does not find the port is not reported",
+			allowedPorts: "[443]",
+			expected:     0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := scanTerraform(t, fmt.Sprintf(`
+variable "allowed_ports" {
+  default = %s
+}
+
+resource "aws_security_group_rule" "ssh" {
+  count       = contains(var.allowed_ports, 22) ? 1 : 0
+  type        = "ingress"
+  description = "ssh"
+  from_port   = 22
+  to_port     = 22
+  cidr_blocks = ["0.0.0.0/0"]
+}
+`, test.allowedPorts))
+			var found int
+			for _, result := range results.GetFailed() {
+				if result.Rule().LongID() == "aws-vpc-no-public-ingress-sgr" {
+					found++
+				}
+			}
+			assert.Equal(t, test.expected, found)
+		})
+	}
+}
+
+func TestForExpressions(t *testing.T) {
+	runEvaluationTests(t, []evaluationTest{
+		{
+			name: "check a map `for` expression produces its keys",
+			source: `
+variable "buckets" {
+  default = {
+    logs = "log-delivery-write"
+    data = "private"
+  }
+}
+
+resource "aws_s3_bucket" "default" {
+  tags = { for name, acl in var.buckets : "acl-${name}" => acl }
+}
+`,
+			matchSpec: MatchSpec{
+				Action:     "hasTag",
+				MatchValue: "acl-data",
+			},
+			expected: true,
+		},
+		{
+			name: "check a list `for` expression transforms each item",
+			source: `
+resource "aws_s3_bucket" "default" {
+  names = [for name in ["logs", "data", "tmp"] : upper(name) if name != "tmp"]
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "names",
+				Action:     "contains",
+				MatchValue: "DATA",
+			},
+			expected: true,
+		},
+		{
+			name: "check a list `for` expression with a filter drops unmatched items",
+			source: `
+resource "aws_s3_bucket" "default" {
+  names = [for name in ["logs", "data", "tmp"] : upper(name) if name != "tmp"]
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "names",
+				Action:     "contains",
+				MatchValue: "TMP",
+			},
+			expected: false,
+		},
+	})
+}
+
+func TestForEachOverForExpressionMap(t *testing.T) {
+	source := `
+variable "buckets" {
+  default = {
+    logs = { public = false }
+    site = { public = true }
+    data = { public = false }
+  }
+}
+
+locals {
+  bucket_acls = { for name, config in var.buckets : name => config.public ? "public-read" : "private" }
+}
+
+resource "aws_s3_bucket" "default" {
+  for_each = local.bucket_acls
+  bucket   = each.key
+  acl      = each.value
+}
+`
+	buckets := make(map[string]string)
+	for _, block := range parseFromSource(t, source)[0].GetResourcesByType("aws_s3_bucket") {
+		buckets[block.GetAttribute("bucket").Value().AsString()] = block.GetAttribute("acl").Value().AsString()
+	}
+	assert.Equal(t, map[string]string{
+		"logs": "private",
+		"site": "public-read",
+		"data": "private",
+	}, buckets, "`for_each` over a `for` expression should expand one block per key.")
+
+	results := scanTerraform(t, source)
+	var public []string
+	for _, result := range results.GetFailed() {
+		if result.Rule().LongID() == "aws-s3-no-public-access-with-acl" {
+			public = append(public, result.Range().String())
+		}
+	}
+	assert.Len(t, public, 1, "only the public bucket from the `for` expression should be reported.")
+}
+
+func TestYAMLFunctions(t *testing.T) {
+	files := map[string]string{
+		"manifests/pod.yaml": `apiVersion: v1
+kind: Pod
+metadata:
+  name: debug
+spec:
+  containers:
+    - name: shell
+      image: busybox
+      securityContext:
+        privileged: true
+`,
+	}
+	runEvaluationTests(t, []evaluationTest{
+		{
+			name: "check `yamldecode` of a file can be navigated to a nested field",
+			source: `
+locals {
+  pod = yamldecode(file("${path.module}/manifests/pod.yaml"))
+}
+
+resource "aws_s3_bucket" "default" {
+  privileged = local.pod.spec.containers[0].securityContext.privileged
+}
+`,
+			files: files,
+			matchSpec: MatchSpec{
+				Name:       "privileged",
+				Action:     "equals",
+				MatchValue: true,
+			},
+			expected: true,
+		},
+		{
+			name: "check `yamlencode` renders a document",
+			source: `
+resource "aws_s3_bucket" "default" {
+  manifest = yamlencode({ kind = "Pod" })
+}
+`,
+			matchSpec: MatchSpec{
+				Name:       "manifest",
+				Action:     "equals",
+				MatchValue: "\"kind\": \"Pod\"\n",
+			},
+			expected: true,
+		},
+	})
+}
+
+func TestStringTrimmingFunctions(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		expected   string
+	}{
+		{name: "trimspace", expression: `trimspace("  logs\n")`, expected: "logs"},
+		{name: "trim", expression: `trim("--logs--", "-")`, expected: "logs"},
+		{name: "trimprefix", expression: `trimprefix("app-logs", "app-")`, expected: "logs"},
+		{name: "trimsuffix", expression: `trimsuffix("logs.example.com", ".example.com")`, expected: "logs"},
+		{name: "substr", expression: `substr("production", 0, 4)`, expected: "prod"},
+		{name: "title", expression: `title("access logs")`, expected: "Access Logs"},
+		{name: "chomp", expression: `chomp("logs\r\n")`, expected: "logs"},
+	}
+	var evaluationTests []evaluationTest
+	for _, test := range tests {
+		evaluationTests = append(evaluationTests, evaluationTest{
+			name: fmt.Sprintf("check `%s` is evaluated", test.name),
+			source: fmt.Sprintf(`
+resource "aws_s3_bucket" "default" {
+  bucket = %s
+}
+`, test.expression),
+			matchSpec: MatchSpec{
+				Name:       "bucket",
+				Action:     "equals",
+				MatchValue: test.expected,
+			},
+			expected: true,
+		})
+	}
+	runEvaluationTests(t, evaluationTests)
+}
+
+func TestNameNormalisedWithTrimprefixIsValidated(t *testing.T) {
+	namingConvention := MatchSpec{
+		Name:       "bucket",
+		Action:     "regexMatches",
+		MatchValue: "^[a-z]+-[a-z]+$",
+	}
+	runEvaluationTests(t, []evaluationTest{
+		{
+			name: "check a name normalised with `trimprefix` follows the naming convention",
+			source: `
+variable "name" {
+  default = "legacy/team-logs"
+}
+
+resource "aws_s3_bucket" "default" {
+  bucket = trimprefix(var.name, "legacy/")
+}
+`,
+			matchSpec: namingConvention,
+			expected:  true,
+		},
+		{
+			name: "check a name normalised with `trimprefix` can still break the naming convention",
+			source: `
+variable "name" {
+  default = "legacy/Team_Logs"
+}
+
+resource "aws_s3_bucket" "default" {
+  bucket = trimprefix(var.name, "legacy/")
+}
+`,
+			matchSpec: namingConvention,
+			expected:  false,
+		},
+	})
+}
+
+func TestHashingFunctions(t *testing.T) {
+	const policy = `{"Version":"2012-10-17","Statement":[]}`
+	sha256Sum := sha256.Sum256([]byte(policy))
+	sha512Sum := sha512.Sum512([]byte(policy))
+	files := map[string]string{
+		"policies/bucket.json": policy,
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		expected   string
+	}{
+		{
+			name:       "base64sha256",
+			expression: fmt.Sprintf("base64sha256(%q)", policy),
+			expected:   base64.StdEncoding.EncodeToString(sha256Sum[:]),
+		},
+		{
+			name:       "base64sha512",
+			expression: fmt.Sprintf("base64sha512(%q)", policy),
+			expected:   base64.StdEncoding.EncodeToString(sha512Sum[:]),
+		},
+		{
+			name:       "filesha256",
+			expression: `filesha256("${path.module}/policies/bucket.json")`,
+			expected:   hex.EncodeToString(sha256Sum[:]),
+		},
+		{
+			name:       "filebase64sha256",
+			expression: `filebase64sha256("${path.module}/policies/bucket.json")`,
+			expected:   base64.StdEncoding.EncodeToString(sha256Sum[:]),
+		},
+	}
+	var evaluationTests []evaluationTest
+	for _, test := range tests {
+		evaluationTests = append(evaluationTests, evaluationTest{
+			name: fmt.Sprintf("check `%s` is evaluated", test.name),
+			source: fmt.Sprintf(`
+resource "aws_s3_bucket" "default" {
+  etag = %s
+}
+`, test.expression),
+			files: files,
+			matchSpec: MatchSpec{
+				Name:       "etag",
+				Action:     "equals",
+				MatchValue: test.expected,
+			},
+			expected: true,
+		})
+	}
+	runEvaluationTests(t, evaluationTests)
+}
@@ -2,10 +2,13 @@ package custom
 
 import (
 	"fmt"
+	"io/fs"
+	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
 	"github.com/zclconf/go-cty/cty/gocty"
 
 	"github.com/aquasecurity/defsec/pkg/providers"
@@ -52,14 +55,14 @@ var matchFunctions = map[CheckAction]func(*terraform.Block, *MatchSpec, *customC
 		if attribute.IsNil() {
 			return spec.IgnoreUndefined
 		}
-		return attribute.Contains(processMatchValueVariables(spec.MatchValue, customCtx.variables), terraform.IgnoreCase)
+		return attributeContains(attribute, processMatchValueVariables(spec.MatchValue, customCtx.variables), true)
 	},
 	NotContains: func(b *terraform.Block, spec *MatchSpec, customCtx *customContext) bool {
 		attribute := b.GetAttribute(spec.Name)
 		if attribute.IsNil() {
 			return spec.IgnoreUndefined
 		}
-		return !attribute.Contains(processMatchValueVariables(spec.MatchValue, customCtx.variables))
+		return !attributeContains(attribute, processMatchValueVariables(spec.MatchValue, customCtx.variables), false)
 	},
 	Equals: func(b *terraform.Block, spec *MatchSpec, customCtx *customContext) bool {
 		attribute := b.GetAttribute(spec.Name)
@@ -115,6 +118,22 @@ var matchFunctions = map[CheckAction]func(*terraform.Block, *MatchSpec, *customC
 		}
 		return attribute.RegexMatches(*regex)
 	},
+	RawRegexMatches: func(b *terraform.Block, spec *MatchSpec, customCtx *customContext) bool {
+		attribute := b.GetAttribute(spec.Name)
+		if attribute.IsNil() {
+			return spec.IgnoreUndefined
+		}
+		raw := processMatchValueVariables(spec.MatchValue, customCtx.variables)
+		regex, err := regexp.Compile(fmt.Sprintf("%v", raw))
+		if err != nil {
+			return false
+		}
+		source, err := rawSource(attribute)
+		if err != nil {
+			return false
+		}
+		return regex.MatchString(source)
+	},
 	RequiresPresence: func(b *terraform.Block, spec *MatchSpec, customCtx *customContext) bool {
 		return resourceFound(spec, customCtx.module)
 	},
@@ -133,10 +152,10 @@ var matchFunctions = map[CheckAction]func(*terraform.Block, *MatchSpec, *customC
 
 var AttrMatchFunctions = map[CheckAction]func(*terraform.Attribute, *MatchSpec, *customContext) bool{
 	IsPresent: func(a *terraform.Attribute, spec *MatchSpec, customCtx *customContext) bool {
-		return a.Contains(spec.Name) || spec.IgnoreUndefined
+		return attributeContains(a, spec.Name, false) || spec.IgnoreUndefined
 	},
 	NotPresent: func(a *terraform.Attribute, spec *MatchSpec, customCtx *customContext) bool {
-		return !a.Contains(spec.Name)
+		return !attributeContains(a, spec.Name, false)
 	},
 	StartsWith: func(a *terraform.Attribute, spec *MatchSpec, customCtx *customContext) bool {
 		if attributeValue := a.MapValue(spec.Name); attributeValue.IsNull() {
@@ -445,6 +464,59 @@ func processMatchValueVariables(matchValue interface{}, variables map[string]str
 	}
 }
 
+// rawSource returns the lines of source code covering the attribute, before any evaluation has taken place
+func rawSource(attribute *terraform.Attribute) (string, error) {
+	rng := attribute.GetMetadata().Range()
+	if rng.GetFS() == nil {
+		return "", fmt.Errorf("range %s has no filesystem", rng)
+	}
+	content, err := fs.ReadFile(rng.GetFS(), filepath.ToSlash(rng.GetLocalFilename()))
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(string(content), "\n")
+	if rng.GetStartLine() < 1 || rng.GetEndLine() > len(lines) || rng.GetStartLine() > rng.GetEndLine() {
+		return "", fmt.Errorf("range %s is outside of the file", rng)
+	}
+	return strings.Join(lines[rng.GetStartLine()-1:rng.GetEndLine()], "\n"), nil
+}
+
+// attributeContains checks the attribute for a value like attribute.Contains, which only supports maps, lists and
+// strings, with the addition of sets such as the results of toset and setunion. Set elements which are not strings
+// are compared with the value converted to their type, so a set of numbers contains 22 and "22" alike.
+func attributeContains(attribute *terraform.Attribute, value interface{}, ignoreCase bool) bool {
+	val := attribute.Value()
+	if !val.IsKnown() || val.IsNull() || !val.Type().IsSetType() {
+		if ignoreCase {
+			return attribute.Contains(value, terraform.IgnoreCase)
+		}
+		return attribute.Contains(value)
+	}
+	lookFor := cty.StringVal(fmt.Sprintf("%v", value))
+	if valueType, err := gocty.ImpliedType(value); err == nil {
+		if converted, err := gocty.ToCtyValue(value, valueType); err == nil {
+			lookFor = converted
+		}
+	}
+	for it := val.ElementIterator(); it.Next(); {
+		_, element := it.Element()
+		if !element.IsKnown() || element.IsNull() {
+			continue
+		}
+		converted, err := convert.Convert(lookFor, element.Type())
+		if err != nil || !converted.IsKnown() || converted.IsNull() {
+			continue
+		}
+		if element.Equals(converted).True() {
+			return true
+		}
+		if ignoreCase && element.Type() == cty.String && strings.EqualFold(element.AsString(), converted.AsString()) {
+			return true
+		}
+	}
+	return false
+}
+
 func resourceFound(spec *MatchSpec, module *terraform.Module) bool {
 	val := fmt.Sprintf("%v", spec.Name)
 	byType := module.GetResourcesByType(val)
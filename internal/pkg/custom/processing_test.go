@@ -993,3 +993,129 @@ func createTestFile(t *testing.T, filename, contents string) fs.FS {
 	require.NoError(t, err)
 	return f
 }
+
+func TestRawRegexMatches(t *testing.T) {
+	var tests = []struct {
+		name               string
+		source             string
+		predicateMatchSpec MatchSpec
+		expected           bool
+	}{
+		{
+			name: "check `rawRegexMatches` matches an unevaluated interpolation",
+			source: `
+variable "prefix" {}
+
+resource "aws_db_instance" "default" {
+  password = "${var.prefix}-hunter2"
+}
+`,
+			predicateMatchSpec: MatchSpec{
+				Name:       "password",
+				Action:     "rawRegexMatches",
+				MatchValue: `"\$\{var\.[a-z]+\}-[a-z0-9]+"`,
+			},
+			expected: true,
+		},
+		{
+			name: "check `rawRegexMatches` does not match the evaluated value",
+			source: `
+locals {
+  secret = "hunter2"
+}
+
+resource "aws_db_instance" "default" {
+  password = local.secret
+}
+`,
+			predicateMatchSpec: MatchSpec{
+				Name:       "password",
+				Action:     "rawRegexMatches",
+				MatchValue: "hunter2",
+			},
+			expected: false,
+		},
+		{
+			name: "check `rawRegexMatches` in attribute-not-found fail case",
+			source: `
+resource "aws_db_instance" "default" {
+  password = "hunter2"
+}
+`,
+			predicateMatchSpec: MatchSpec{
+				Name:       "not-password",
+				Action:     "rawRegexMatches",
+				MatchValue: "hunter2",
+			},
+			expected: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			block := parseFromSource(t, test.source)[0].GetResourcesByType("aws_db_instance")[0]
+			result := evalMatchSpec(block, &test.predicateMatchSpec, NewEmptyCustomContext())
+			assert.Equal(t, test.expected, result, "`rawRegexMatches` match function evaluating incorrectly.")
+		})
+	}
+}
+
+var testLifecycleSource = `
+resource "aws_s3_bucket" "default" {
+  bucket = "prod-logs"
+
+  tags = {
+    Environment = "prod"
+  }
+
+  lifecycle {
+    prevent_destroy = true
+    ignore_changes  = [tags]
+  }
+}
+`
+
+func TestLifecycleBlocks(t *testing.T) {
+	var tests = []struct {
+		name      string
+		matchSpec MatchSpec
+		expected  bool
+	}{
+		{
+			name: "check `lifecycle` meta-block can be inspected",
+			matchSpec: MatchSpec{
+				Name:   "lifecycle",
+				Action: "isPresent",
+				SubMatch: &MatchSpec{
+					Name:       "prevent_destroy",
+					Action:     "equals",
+					MatchValue: true,
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "check `lifecycle` meta-block is not mistaken for a nested resource block",
+			matchSpec: MatchSpec{
+				Name:   "lifecycle_rule",
+				Action: "isPresent",
+			},
+			expected: false,
+		},
+		{
+			name: "check `ignore_changes` does not hide the attributes it references",
+			matchSpec: MatchSpec{
+				Action:     "hasTag",
+				MatchValue: "Environment",
+			},
+			expected: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			module := parseFromSource(t, testLifecycleSource)[0]
+			block := module.GetResourcesByType("aws_s3_bucket")[0]
+			result := evalMatchSpec(block, &test.matchSpec, NewCustomContext(module))
+			assert.Equal(t, test.expected, result, "`lifecycle` block handled incorrectly.")
+		})
+	}
+}
@@ -11,13 +11,15 @@ import (
 
 	"github.com/aquasecurity/defsec/pkg/formatters"
 	"github.com/aquasecurity/defsec/pkg/severity"
+	"github.com/aquasecurity/defsec/pkg/terraform"
+	"github.com/aquasecurity/tfsec/internal/pkg/ordering"
 	"github.com/liamg/clinch/terminal"
 	"github.com/liamg/tml"
 )
 
 var severityFormat map[severity.Severity]string
 
-func DefaultWithMetrics(metrics scanner.Metrics, conciseOutput bool, codeTheme string, withColours bool, noCode bool) func(b formatters.ConfigurableFormatter, results scan.Results) error {
+func DefaultWithMetrics(metrics scanner.Metrics, conciseOutput bool, codeTheme string, withColours bool, noCode bool, flattenModules bool) func(b formatters.ConfigurableFormatter, results scan.Results) error {
 	return func(b formatters.ConfigurableFormatter, results scan.Results) error {
 
 		// turn on no-code if consise output required
@@ -58,7 +60,7 @@ func DefaultWithMetrics(metrics scanner.Metrics, conciseOutput bool, codeTheme s
 
 		_, _ = fmt.Fprintln(b.Writer(), "")
 		for _, group := range groups {
-			printResult(b, group, codeTheme, withColours, noCode)
+			printResult(b, group, codeTheme, withColours, noCode, flattenModules)
 		}
 
 		if !conciseOutput {
@@ -141,9 +143,35 @@ func getOccurrences(first scan.Result, baseDir string) []simpleLocation {
 	return via
 }
 
+// flattenedAddress returns the address of the block which caused the result, prefixed with the
+// address of every module it was found in, outermost first e.g. module.a.module.b.aws_s3_bucket.x
+func flattenedAddress(result scan.Result) string {
+	m := result.Metadata()
+	address := m.Reference().String()
+	for parent := m.Parent(); parent != nil; parent = parent.Parent() {
+		if ref, ok := parent.Reference().(*terraform.Reference); ok && ref.BlockType().Name() == "module" {
+			address = fmt.Sprintf("%s.%s", ref.String(), address)
+		}
+	}
+	return address
+}
+
+// lowestInstance returns the result in the group with the lowest count/for_each keys, so that the address
+// shown for a group does not depend on the order its checks finished in. The results are sorted before they
+// are written, but grouping sorts them again by group only, which can reorder the results within a group.
+func lowestInstance(results []scan.Result) scan.Result {
+	lowest := results[0]
+	for _, result := range results[1:] {
+		if ordering.CompareInstances(result, lowest) < 0 {
+			lowest = result
+		}
+	}
+	return lowest
+}
+
 // nolint
 func printResult(b formatters.ConfigurableFormatter, group formatters.GroupedResult, theme string, withColours bool,
-	noCode bool) {
+	noCode bool, flattenModules bool) {
 
 	first := group.Results()[0]
 
@@ -205,6 +233,14 @@ func printResult(b formatters.ConfigurableFormatter, group formatters.GroupedRes
 			filename,
 			lineInfo,
 		)
+		if flattenModules && len(via) > 0 {
+			_ = tml.Fprintf(
+				w,
+				"  <dim>as </dim><italic>%s\n",
+				flattenedAddress(lowestInstance(group.Results())),
+			)
+			via = nil
+		}
 		for i, v := range via {
 			_ = tml.Fprintf(
 				w,
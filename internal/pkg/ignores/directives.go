@@ -0,0 +1,156 @@
+package ignores
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Directive is a single tfsec:ignore comment found in a terraform file.
+type Directive struct {
+	Filename  string            `json:"filename"`
+	Line      int               `json:"line"`
+	RuleID    string            `json:"rule_id"`
+	Params    map[string]string `json:"params,omitempty"`
+	Expiry    *time.Time        `json:"expiry,omitempty"`
+	Workspace string            `json:"workspace,omitempty"`
+}
+
+// Directives is the set of ignore comments found below a directory, in file and line order.
+type Directives []Directive
+
+// this follows the comment syntax accepted by the scanner, so that the directives reported here match the
+// ones which are applied to results
+var directiveCommentPattern = regexp.MustCompile(`^\s*([/]+|/\*|#)\s*tfsec:`)
+
+// Find returns every ignore directive in the terraform files below dir, or in dir itself if it is a file.
+// Filenames are relative to dir, and .terraform directories are skipped.
+func Find(dir string) (Directives, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return FindFS(os.DirFS(filepath.Dir(dir)), filepath.Base(dir))
+	}
+	return FindFS(os.DirFS(dir), ".")
+}
+
+// FindFS returns every ignore directive in the terraform files below dir in the given filesystem, or in dir
+// itself if it is a file. Filenames are relative to dir, or are the name of the file if dir is a file.
+//
+// JSON configuration is read too, as the scanner looks for directives in the lines of every file it loads.
+// JSON has no comments, so no directive is found in a JSON file in practice, by the scanner or here.
+func FindFS(target fs.FS, dir string) (Directives, error) {
+	info, err := fs.Stat(target, dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return findInFile(target, dir, path.Base(dir))
+	}
+
+	var directives Directives
+	if err := fs.WalkDir(target, dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".terraform" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(filePath, ".tf") && !strings.HasSuffix(filePath, ".tf.json") {
+			return nil
+		}
+		rel := strings.TrimPrefix(filePath, dir+"/")
+		if dir == "." {
+			rel = filePath
+		}
+		fileDirectives, err := findInFile(target, filePath, rel)
+		if err != nil {
+			return err
+		}
+		directives = append(directives, fileDirectives...)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return directives, nil
+}
+
+func findInFile(target fs.FS, filePath string, name string) (Directives, error) {
+	f, err := target.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var directives Directives
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if !strings.Contains(text, "tfsec:") {
+			continue
+		}
+		text = directiveCommentPattern.ReplaceAllString(text, "tfsec:")
+		for _, comment := range strings.Split(text, " ") {
+			comment = strings.TrimSpace(comment)
+			comment = strings.TrimPrefix(comment, "#")
+			comment = strings.TrimPrefix(comment, "//")
+			comment = strings.TrimPrefix(comment, "/*")
+			if !strings.HasPrefix(comment, "tfsec:") {
+				continue
+			}
+			if directive, ok := parseDirective(strings.TrimPrefix(comment, "tfsec:")); ok {
+				directive.Filename = name
+				directive.Line = line
+				directives = append(directives, directive)
+			}
+		}
+	}
+	return directives, scanner.Err()
+}
+
+func parseDirective(comment string) (Directive, bool) {
+	var directive Directive
+	segments := strings.Split(comment, ":")
+	for i := 0; i < len(segments)-1; i += 2 {
+		key, val := segments[i], segments[i+1]
+		switch key {
+		case "ignore":
+			directive.RuleID, directive.Params = parseIDWithParams(val)
+		case "exp":
+			expiry, err := time.Parse("2006-01-02", val)
+			if err != nil {
+				return directive, false
+			}
+			directive.Expiry = &expiry
+		case "ws":
+			directive.Workspace = val
+		}
+	}
+	return directive, directive.RuleID != ""
+}
+
+func parseIDWithParams(input string) (string, map[string]string) {
+	if !strings.Contains(input, "[") {
+		return input, nil
+	}
+	parts := strings.Split(input, "[")
+	id := parts[0]
+	params := make(map[string]string)
+	for _, pair := range strings.Split(strings.TrimSuffix(parts[1], "]"), ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) == 2 {
+			params[kv[0]] = kv[1]
+		}
+	}
+	return id, params
+}
@@ -0,0 +1,170 @@
+package ignores_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/aquasecurity/tfsec/internal/pkg/ignores"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(t *testing.T, value string) *time.Time {
+	parsed, err := time.Parse("2006-01-02", value)
+	require.NoError(t, err)
+	return &parsed
+}
+
+func TestFindDirectives(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected ignores.Directives
+	}{
+		{
+			name: "comment above block",
+			content: `# tfsec:ignore:aws-s3-enable-versioning
+resource "aws_s3_bucket" "a" {}`,
+			expected: ignores.Directives{
+				{Filename: "main.tf", Line: 1, RuleID: "aws-s3-enable-versioning"},
+			},
+		},
+		{
+			name: "trailing comment",
+			content: `resource "aws_s3_bucket" "a" {
+  acl = "public-read" #tfsec:ignore:aws-s3-no-public-access-with-acl
+}`,
+			expected: ignores.Directives{
+				{Filename: "main.tf", Line: 2, RuleID: "aws-s3-no-public-access-with-acl"},
+			},
+		},
+		{
+			name: "slash comments",
+			content: `// tfsec:ignore:aws-s3-enable-versioning
+/* tfsec:ignore:aws-s3-enable-logging */
+resource "aws_s3_bucket" "a" {}`,
+			expected: ignores.Directives{
+				{Filename: "main.tf", Line: 1, RuleID: "aws-s3-enable-versioning"},
+				{Filename: "main.tf", Line: 2, RuleID: "aws-s3-enable-logging"},
+			},
+		},
+		{
+			name:    "several directives on one line",
+			content: `#tfsec:ignore:aws-s3-enable-versioning tfsec:ignore:aws-s3-enable-logging`,
+			expected: ignores.Directives{
+				{Filename: "main.tf", Line: 1, RuleID: "aws-s3-enable-versioning"},
+				{Filename: "main.tf", Line: 1, RuleID: "aws-s3-enable-logging"},
+			},
+		},
+		{
+			name:    "params",
+			content: `#tfsec:ignore:aws-vpc-no-public-ingress-sgr[from_port=22,to_port=22]`,
+			expected: ignores.Directives{
+				{
+					Filename: "main.tf",
+					Line:     1,
+					RuleID:   "aws-vpc-no-public-ingress-sgr",
+					Params:   map[string]string{"from_port": "22", "to_port": "22"},
+				},
+			},
+		},
+		{
+			name:    "expiry",
+			content: `#tfsec:ignore:aws-s3-enable-versioning:exp:2025-01-31`,
+			expected: ignores.Directives{
+				{Filename: "main.tf", Line: 1, RuleID: "aws-s3-enable-versioning", Expiry: date(t, "2025-01-31")},
+			},
+		},
+		{
+			name:    "workspace",
+			content: `#tfsec:ignore:aws-s3-enable-versioning:ws:production`,
+			expected: ignores.Directives{
+				{Filename: "main.tf", Line: 1, RuleID: "aws-s3-enable-versioning", Workspace: "production"},
+			},
+		},
+		{
+			name:    "expiry and workspace",
+			content: `#tfsec:ignore:aws-s3-enable-versioning:exp:2025-01-31:ws:production`,
+			expected: ignores.Directives{
+				{
+					Filename:  "main.tf",
+					Line:      1,
+					RuleID:    "aws-s3-enable-versioning",
+					Expiry:    date(t, "2025-01-31"),
+					Workspace: "production",
+				},
+			},
+		},
+		{
+			name:    "malformed expiry",
+			content: `#tfsec:ignore:aws-s3-enable-versioning:exp:31-01-2025`,
+		},
+		{
+			name:    "no rule",
+			content: `#tfsec:ws:production`,
+		},
+		{
+			name:    "missing rule",
+			content: `#tfsec:ignore`,
+		},
+		{
+			name:    "unknown key",
+			content: `#tfsec:ignore:aws-s3-enable-versioning:reason:legacy`,
+			expected: ignores.Directives{
+				{Filename: "main.tf", Line: 1, RuleID: "aws-s3-enable-versioning"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			target := fstest.MapFS{"main.tf": {Data: []byte(test.content)}}
+			directives, err := ignores.FindFS(target, ".")
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, directives)
+		})
+	}
+}
+
+func TestFindDirectivesWalksTerraformFiles(t *testing.T) {
+	target := fstest.MapFS{
+		"project/main.tf":                           {Data: []byte("#tfsec:ignore:aws-s3-enable-versioning\n")},
+		"project/modules/bucket/main.tf":            {Data: []byte("\n#tfsec:ignore:aws-s3-enable-logging\n")},
+		"project/README.md":                         {Data: []byte("#tfsec:ignore:aws-s3-encryption-customer-key\n")},
+		"project/.terraform/modules/remote/main.tf": {Data: []byte("#tfsec:ignore:aws-s3-block-public-acls\n")},
+		"project/main.tf.json": {Data: []byte(`{
+  "//": "#tfsec:ignore:aws-s3-block-public-policy",
+  "resource": {}
+}`)},
+	}
+
+	directives, err := ignores.FindFS(target, "project")
+	require.NoError(t, err)
+	assert.Equal(t, ignores.Directives{
+		{Filename: "main.tf", Line: 1, RuleID: "aws-s3-enable-versioning"},
+		{Filename: "modules/bucket/main.tf", Line: 2, RuleID: "aws-s3-enable-logging"},
+	}, directives, "JSON files have no comments, so they should not contain directives")
+
+	directives, err = ignores.FindFS(target, "project/modules/bucket/main.tf")
+	require.NoError(t, err)
+	assert.Equal(t, ignores.Directives{
+		{Filename: "main.tf", Line: 2, RuleID: "aws-s3-enable-logging"},
+	}, directives)
+}
+
+func TestFindDirectivesOnDisk(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte("#tfsec:ignore:aws-s3-enable-versioning\n"), 0o600))
+
+	expected := ignores.Directives{{Filename: "main.tf", Line: 1, RuleID: "aws-s3-enable-versioning"}}
+	directives, err := ignores.Find(dir)
+	require.NoError(t, err)
+	assert.Equal(t, expected, directives)
+
+	directives, err = ignores.Find(filepath.Join(dir, "main.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, expected, directives)
+}
@@ -0,0 +1,79 @@
+package ordering
+
+import (
+	"sort"
+
+	"github.com/aquasecurity/defsec/pkg/scan"
+	"github.com/aquasecurity/defsec/pkg/terraform"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Sort orders results deterministically. The scanner sorts by rule and range only, which leaves the
+// instances of a counted or for_each resource or module in the order their checks happened to finish in, so
+// ties are broken with CompareInstances.
+func Sort(results scan.Results) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		switch {
+		case a.Rule().LongID() != b.Rule().LongID():
+			return a.Rule().LongID() < b.Rule().LongID()
+		case a.Range().String() != b.Range().String():
+			// match the ordering used by the scanner
+			return a.Range().String() > b.Range().String()
+		}
+		if c := CompareInstances(a, b); c != 0 {
+			return c < 0
+		}
+		if a.Status() != b.Status() {
+			return a.Status() < b.Status()
+		}
+		return a.Description() < b.Description()
+	})
+}
+
+// CompareInstances compares the count/for_each keys of the blocks which caused the results and of the modules
+// they are in, outermost module first. Numeric count indexes are compared numerically and for_each keys
+// lexically.
+func CompareInstances(a, b scan.Result) int {
+	ak, bk := instanceKeys(a), instanceKeys(b)
+	for i := 0; i < len(ak) && i < len(bk); i++ {
+		if c := compareInstanceKey(ak[i], bk[i]); c != 0 {
+			return c
+		}
+	}
+	return len(ak) - len(bk)
+}
+
+func instanceKeys(result scan.Result) []cty.Value {
+	var keys []cty.Value
+	for m := result.Metadata(); ; m = *m.Parent() {
+		if ref, ok := m.Reference().(*terraform.Reference); ok && ref.KeyBracketed() != "" {
+			keys = append([]cty.Value{ref.RawKey()}, keys...)
+		}
+		if m.Parent() == nil {
+			break
+		}
+	}
+	return keys
+}
+
+func compareInstanceKey(a, b cty.Value) int {
+	if a.Type() == cty.Number && b.Type() == cty.Number && a.IsKnown() && b.IsKnown() {
+		return a.AsBigFloat().Cmp(b.AsBigFloat())
+	}
+	as, bs := keyString(a), keyString(b)
+	switch {
+	case as < bs:
+		return -1
+	case as > bs:
+		return 1
+	}
+	return 0
+}
+
+func keyString(key cty.Value) string {
+	if key.Type() == cty.String && key.IsKnown() && !key.IsNull() {
+		return key.AsString()
+	}
+	return key.GoString()
+}
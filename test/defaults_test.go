@@ -3,12 +3,14 @@ package test
 import (
 	"fmt"
 	"runtime"
+	"sort"
 	"testing"
 
 	"github.com/aquasecurity/defsec/pkg/rules"
 	"github.com/aquasecurity/defsec/pkg/scan"
 	"github.com/aquasecurity/defsec/pkg/state"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_Failure(t *testing.T) {
@@ -41,6 +43,32 @@ func Test_BadHCL(t *testing.T) {
 	assert.Equal(t, 1, exit)
 }
 
+func Test_ExperimentsWarning(t *testing.T) {
+	out, stderr, exit := runWithArgs("./testdata/experiments", "-f", "json")
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, stderr, "WARNING: main.tf:2 opts into the Terraform experiment module_variable_optional_attrs")
+	assert.Contains(t, stderr, "optional object attributes are not added as null when omitted")
+	assert.Greater(t, len(parseJSON(t, out)), 0, "the module should still be scanned")
+
+	// the optional acl is not added as null, so coalesce fails and the acl is not evaluated either way
+	findings := func(dir string) []string {
+		out, _, _ := runWithArgs(dir, "-f", "json")
+		var ids []string
+		for _, result := range parseJSON(t, out) {
+			ids = append(ids, result.LongID)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+	assert.Equal(t, findings("./testdata/experiments-stable"), findings("./testdata/experiments"), "opting into the experiment should not change the findings")
+	out, _, exit = runWithArgs("./testdata/experiments", "--print-evaluated-config")
+	assert.Equal(t, 0, exit)
+	assert.Contains(t, out, `"acl": null`)
+
+	_, stderr, _ = runWithArgs("./testdata/experiments-stable", "-f", "json")
+	assert.NotContains(t, stderr, "WARNING")
+}
+
 func Test_ColouredOutputByDefault(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("colours are not supported on windows")
@@ -101,3 +129,122 @@ func Test_WorkspaceDefault(t *testing.T) {
 	assert.Greater(t, len(results), 0)
 	assert.Equal(t, 1, exit)
 }
+
+func Test_SingleFile(t *testing.T) {
+	out, _, exit := runWithArgs("./testdata/single-file/main.tf", "-f", "json")
+	results := parseJSON(t, out)
+	require.Greater(t, len(results), 0)
+	for _, result := range results {
+		assert.Equal(t, "aws_s3_bucket.standalone", result.Resource, "only the provided file should be scanned, without its modules")
+	}
+	assert.Equal(t, 1, exit)
+}
+
+func Test_SingleFileNotTerraform(t *testing.T) {
+	_, err, exit := runWithArgs("./setup_test.go")
+	assert.Contains(t, err, "provided path is not a dir, a terraform file or a terraform plan in json format")
+	assert.Equal(t, 1, exit)
+}
+
+func Test_InstanceOrderIsDeterministic(t *testing.T) {
+	expected := []string{
+		"aws_s3_bucket.counted[0]", "aws_s3_bucket.counted[1]", "aws_s3_bucket.counted[2]",
+		"aws_s3_bucket.counted[3]", "aws_s3_bucket.counted[4]", "aws_s3_bucket.counted[5]",
+		"aws_s3_bucket.counted[6]", "aws_s3_bucket.counted[7]", "aws_s3_bucket.counted[8]",
+		"aws_s3_bucket.counted[9]", "aws_s3_bucket.counted[10]", "aws_s3_bucket.counted[11]",
+		`aws_s3_bucket.mapped["alpha"]`, `aws_s3_bucket.mapped["bravo"]`, `aws_s3_bucket.mapped["charlie"]`,
+		`aws_s3_bucket.mapped["mike"]`, `aws_s3_bucket.mapped["yankee"]`, `aws_s3_bucket.mapped["zulu"]`,
+	}
+	for i := 0; i < 3; i++ {
+		out, _, exit := runWithArgs("./testdata/instance-order", "-f", "json")
+		assert.Equal(t, 1, exit)
+		var resources []string
+		for _, result := range parseJSON(t, out) {
+			if result.LongID == "aws-s3-enable-versioning" {
+				resources = append(resources, result.Resource)
+			}
+		}
+		assert.Equal(t, expected, resources)
+	}
+}
+
+func Test_PlanFile(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/plan/plan.json", "-f", "json")
+	assert.Equal(t, "", err)
+	var planned bool
+	for _, result := range parseJSON(t, out) {
+		if result.Resource == "aws_s3_bucket.planbucket" {
+			planned = true
+		}
+	}
+	assert.True(t, planned, "results should be reported for the planned resources")
+	assert.Equal(t, 1, exit)
+}
+
+func Test_CDKTFSynthOutput(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/cdktf", "-f", "json", "--include-passed")
+	assert.Equal(t, "", err)
+	assert.Equal(t, 1, exit)
+	statuses := make(map[string]scan.Status)
+	for _, result := range parseJSON(t, out) {
+		assert.NotContains(t, result.Resource, "//", "cdktf metadata should not be treated as a block")
+		if result.Resource == "aws_s3_bucket.bucket" {
+			statuses[result.LongID] = result.Status
+		}
+	}
+	assert.Equal(t, scan.StatusFailed, statuses["aws-s3-enable-versioning"])
+	// the public access block refers to the bucket using a cdktf ${} reference
+	assert.Equal(t, scan.StatusPassed, statuses["aws-s3-block-public-acls"])
+}
+
+func Test_ModulesJSONFormats(t *testing.T) {
+	for _, layout := range []string{"legacy", "current"} {
+		t.Run(layout, func(t *testing.T) {
+			out, err, exit := runWithArgs("./testdata/modules-json/"+layout, "-f", "json", "--no-module-downloads")
+			assert.Equal(t, "", err)
+			assert.Equal(t, 1, exit)
+			var found bool
+			for _, result := range parseJSON(t, out) {
+				if result.LongID == "aws-s3-no-public-access-with-acl" {
+					found = true
+					assert.Contains(t, result.Location.Filename, ".terraform/modules/bucket/main.tf")
+				}
+			}
+			assert.True(t, found, "the registry module should be loaded from the directory in modules.json")
+		})
+	}
+}
+
+func Test_ModuleSourceFromLocals(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/computed-source", "-f", "json", "--no-module-downloads", "--lenient-module-sources")
+	assert.Equal(t, "", err)
+	assert.Equal(t, 1, exit)
+	var found bool
+	for _, result := range parseJSON(t, out) {
+		if result.LongID == "aws-s3-no-public-access-with-acl" {
+			found = true
+			assert.Contains(t, result.Location.Filename, "modules/bucket/main.tf")
+		}
+	}
+	assert.True(t, found, "the module should be loaded from the source built from locals and variables")
+
+	out, err, exit = runWithArgs("./testdata/computed-source", "-f", "json", "--no-module-downloads")
+	assert.Equal(t, "WARNING: main.tf:9-11 The source of module 'module.bucket' is built from locals or variables, which terraform does not accept, so the module is not scanned. Use --lenient-module-sources to scan it.\n", err)
+	assert.Equal(t, 0, exit)
+	assert.NotContains(t, out, "modules/bucket/main.tf", "the module should only be scanned with --lenient-module-sources")
+}
+
+func Test_GrandchildModuleRelativeSource(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/grandchild-source", "-f", "json", "--module-chain")
+	assert.Equal(t, "", err)
+	assert.Equal(t, 1, exit)
+	var found bool
+	for _, result := range parseJSON(t, out) {
+		if result.LongID == "aws-s3-no-public-access-with-acl" {
+			found = true
+			assert.Contains(t, result.Location.Filename, "grandchild-source/modules/shared/main.tf")
+			assert.Contains(t, result.Description, "module.a → module.child → module.shared → aws_s3_bucket.shared")
+		}
+	}
+	assert.True(t, found, "the ../ source should be resolved against the directory of the calling module")
+}
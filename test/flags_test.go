@@ -3,11 +3,16 @@ package test
 import (
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/aquasecurity/defsec/pkg/scan"
 	"github.com/aquasecurity/tfsec/version"
 	"github.com/stretchr/testify/require"
 
@@ -41,6 +46,88 @@ func Test_Flag_IgnoreHCLErrors(t *testing.T) {
 	assert.Equal(t, 0, exit)
 }
 
+func Test_Flag_HCLErrorsAsResults(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/hcl-errors", "--hcl-errors-as-results", "-f", "json")
+	assert.Equal(t, "", err)
+	var parseErrors, valid int
+	for _, result := range parseJSON(t, out) {
+		switch {
+		case result.LongID == "general-terraform-parse-error":
+			parseErrors++
+			assert.True(t, strings.HasSuffix(result.Location.Filename, "broken.tf"))
+		case result.Resource == "aws_s3_bucket.valid":
+			valid++
+		}
+	}
+	assert.Equal(t, 1, parseErrors)
+	assert.Greater(t, valid, 0, "files which parse should still be scanned")
+	assert.Equal(t, 1, exit)
+
+	out, _, _ = runWithArgs("./testdata/hcl-errors", "--hcl-errors-as-results", "-f", "sarif")
+	assert.Contains(t, out, "general-terraform-parse-error")
+}
+
+func Test_Flag_HCLErrorsAsResultsAreFiltered(t *testing.T) {
+	parseErrors := func(target string, args ...string) (failed int, ignored int) {
+		out, _, _ := runWithArgs(append([]string{target, "--hcl-errors-as-results", "--include-ignored", "-f", "json"}, args...)...)
+		for _, result := range parseJSON(t, out) {
+			if result.LongID != "general-terraform-parse-error" {
+				continue
+			}
+			switch result.Status {
+			case scan.StatusFailed:
+				failed++
+			case scan.StatusIgnored:
+				ignored++
+			}
+		}
+		return failed, ignored
+	}
+
+	failed, _ := parseErrors("./testdata/hcl-errors")
+	assert.Equal(t, 1, failed)
+	failed, _ = parseErrors("./testdata/hcl-errors", "--exclude", "general-terraform-parse-error")
+	assert.Equal(t, 0, failed, "excluded rules should apply to parse errors")
+	failed, _ = parseErrors("./testdata/hcl-errors", "--minimum-severity", "CRITICAL")
+	assert.Equal(t, 0, failed, "the minimum severity should apply to parse errors")
+	failed, _ = parseErrors("./testdata/hcl-errors", "--exclude-path", "broken.tf")
+	assert.Equal(t, 0, failed, "excluded paths should apply to parse errors")
+	failed, ignored := parseErrors("./testdata/hcl-errors-ignored")
+	assert.Equal(t, 0, failed)
+	assert.Equal(t, 1, ignored, "inline ignores in the file which could not be parsed should apply")
+	failed, _ = parseErrors("./testdata/hcl-errors-ignored", "--no-ignores")
+	assert.Equal(t, 1, failed)
+
+	_, stderr, exit := runWithArgs("./testdata/hcl-errors", "--hcl-errors-as-results", "--baseline-dir", "./testdata/hcl-errors")
+	assert.Equal(t, 0, exit, "parse errors which are in the baseline should not fail the scan")
+	assert.Contains(t, stderr, "0 new")
+
+	// the metrics come from the scanner, so the exit code follows the parse errors which remain
+	_, _, exit = runWithArgs("./testdata/hcl-errors", "--hcl-errors-as-results", "--filter-results", "general-terraform-parse-error")
+	assert.Equal(t, 1, exit)
+	_, _, exit = runWithArgs("./testdata/hcl-errors", "--hcl-errors-as-results", "--filter-results", "general-terraform-parse-error", "--exclude", "general-terraform-parse-error")
+	assert.Equal(t, 0, exit)
+}
+
+func Test_Flag_HCLErrorsAsResultsIncludesModuleLoadErrors(t *testing.T) {
+	out, _, exit := runWithArgs("./testdata/module-load-errors", "--hcl-errors-as-results", "--include-ignored", "-f", "json")
+	assert.Equal(t, 1, exit)
+	statuses := make(map[string]scan.Status)
+	for _, result := range parseJSON(t, out) {
+		if result.LongID == "general-terraform-module-load-error" {
+			statuses[result.Resource] = result.Status
+			assert.True(t, strings.HasSuffix(result.Location.Filename, "main.tf"))
+		}
+	}
+	assert.Equal(t, map[string]scan.Status{
+		"module.missing": scan.StatusFailed,
+		"module.ignored": scan.StatusIgnored,
+	}, statuses, "only the module calls which did not load should be reported")
+
+	out, _, _ = runWithArgs("./testdata/module-load-errors", "-f", "json")
+	assert.NotContains(t, out, "general-terraform-module-load-error", "module load errors should only be reported with the flag")
+}
+
 func Test_Flag_NoColour(t *testing.T) {
 	out, _, exit := runWithArgs("./testdata/pass", "--no-colour")
 	assert.NotContains(t, out, "\x1b[")
@@ -322,6 +409,21 @@ func Test_Flag_CustomCheckDir(t *testing.T) {
 	assert.Equal(t, 1, exit)
 }
 
+func Test_Flag_Timestamp(t *testing.T) {
+	// the custom check expects timeadd(timestamp(), "720h") to be 30 days after the fixed time
+	out, _, exit := runWithArgs("./testdata/timestamp", "--custom-check-dir", "./testdata/timestamp", "-f", "json", "--timestamp", "2022-03-01T12:00:00Z")
+	assert.Len(t, parseJSON(t, out), 0)
+	assert.Equal(t, 0, exit)
+
+	out, _, exit = runWithArgs("./testdata/timestamp", "--custom-check-dir", "./testdata/timestamp", "-f", "json")
+	assert.NotEmpty(t, parseJSON(t, out), "timestamp() should use the current time when no time is given")
+	assert.Equal(t, 1, exit)
+
+	_, stderr, exit := runWithArgs("./testdata/timestamp", "--timestamp", "yesterday")
+	assert.Contains(t, stderr, "'yesterday' is not an RFC 3339 time")
+	assert.Equal(t, 1, exit)
+}
+
 func Test_Flag_ConfigFile(t *testing.T) {
 	out, err, exit := runWithArgs("./testdata/config", "--config-file", "./testdata/config/config.yml")
 	results := parseLovely(t, out)
@@ -330,6 +432,19 @@ func Test_Flag_ConfigFile(t *testing.T) {
 	assert.Equal(t, 1, exit)
 }
 
+func Test_Flag_ConfigFile_WithLoaderOptions(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/config-loader-options")
+	assert.Greater(t, len(parseLovely(t, out)), 0, "results should be detected if the tfvars file from the config has been applied")
+	assert.Equal(t, "", err)
+	assert.Equal(t, 1, exit)
+}
+
+func Test_Flag_ConfigFile_LoaderOptionsOverriddenByFlags(t *testing.T) {
+	out, _, exit := runWithArgs("./testdata/config-loader-options", "--var-file", "./testdata/config-loader-options/vars/none.tfvars")
+	assert.Len(t, parseLovely(t, out), 0, "the tfvars file given as a flag should take precedence over the config file")
+	assert.Equal(t, 0, exit)
+}
+
 func Test_Flag_Debug(t *testing.T) {
 	// use json to ensure all debug goes to stderr and does not break json format
 	for _, flag := range []string{"--debug", "--verbose"} {
@@ -406,6 +521,16 @@ func Test_Flag_Workspace(t *testing.T) {
 	assert.Equal(t, 0, exit)
 }
 
+func Test_Flag_WorkspaceSelectsResources(t *testing.T) {
+	out, _, exit := runWithArgs("./testdata/workspace-prod")
+	assert.Len(t, parseLovely(t, out), 0)
+	assert.Equal(t, 0, exit)
+
+	out, _, exit = runWithArgs("./testdata/workspace-prod", "--workspace", "prod")
+	assert.Greater(t, len(parseLovely(t, out)), 0, "resources counted in the prod workspace should be scanned")
+	assert.Equal(t, 1, exit)
+}
+
 func Test_Flag_MinimumSeverity(t *testing.T) {
 	before, err, _ := runWithArgs("./testdata/fail")
 	assert.Equal(t, "", err)
@@ -510,3 +635,691 @@ func Test_Flag_CustomCheckUrl(t *testing.T) {
 	assert.Len(t, result, 55)
 	assert.Equal(t, 1, exit)
 }
+
+func Test_Flag_MergeInstances(t *testing.T) {
+	before, _, exit := runWithArgs("./testdata/instances", "-f", "json")
+	assert.Equal(t, 1, exit)
+	var found int
+	for _, result := range parseJSON(t, before) {
+		if result.LongID == "aws-s3-enable-versioning" {
+			found++
+		}
+	}
+	assert.Equal(t, 3, found)
+
+	after, _, exit := runWithArgs("./testdata/instances", "-f", "json", "--merge-instances")
+	assert.Equal(t, 1, exit)
+	var merged []string
+	for _, result := range parseJSON(t, after) {
+		if result.LongID == "aws-s3-enable-versioning" {
+			merged = append(merged, result.Description)
+		}
+	}
+	require.Len(t, merged, 1)
+	assert.Contains(t, merged[0], "(instances: a, b, c)")
+}
+
+func Test_Flag_TfvarsFileGlob(t *testing.T) {
+	out, _, exit := runWithArgs("./testdata/tfvars/tf", "--var-file", "./testdata/tfvars/env/prod/*.tfvars")
+	assert.Greater(t, len(parseLovely(t, out)), 0, "results should be detected if the last matched tfvars file has been applied")
+	assert.Equal(t, 1, exit)
+}
+
+func Test_Flag_TfvarsFileGlobWithoutMatches(t *testing.T) {
+	_, err, exit := runWithArgs("./testdata/tfvars/tf", "--var-file", "./testdata/tfvars/env/missing/*.tfvars")
+	assert.Contains(t, err, "no tfvars files matched './testdata/tfvars/env/missing/*.tfvars'")
+	assert.Equal(t, 1, exit)
+}
+
+func Test_Flag_VarFileFromStdin(t *testing.T) {
+	tests := []struct {
+		name  string
+		stdin string
+		args  []string
+	}{
+		{name: "hcl", stdin: "bucket_count = 1\n"},
+		{name: "json", stdin: `{"bucket_count": 1}`},
+		{name: "explicit format", stdin: `{"bucket_count": 1}`, args: []string{"--var-file-stdin-format", "json"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, _, exit := runWithStdin(test.stdin, append([]string{"./testdata/tfvars/tf", "--var-file=-"}, test.args...)...)
+			assert.Greater(t, len(parseLovely(t, out)), 0, "results should be detected if the tfvars from stdin have been applied")
+			assert.Equal(t, 1, exit)
+		})
+	}
+
+	t.Run("order", func(t *testing.T) {
+		out, _, exit := runWithStdin("bucket_count = 1\n", "./testdata/tfvars/tf", "--var-file=-", "--var-file", "./testdata/tfvars/env/prod/a.tfvars")
+		assert.Len(t, parseLovely(t, out), 0, "a later tfvars file should take precedence over stdin")
+		assert.Equal(t, 0, exit)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		_, err, exit := runWithStdin("bucket_count = 1\n", "./testdata/tfvars/tf", "--var-file=-", "--var-file-stdin-format", "yaml")
+		assert.Contains(t, err, "'yaml' is not a valid tfvars format")
+		assert.Equal(t, 1, exit)
+	})
+}
+
+func Test_Flag_FlattenModules(t *testing.T) {
+	before, _, _ := runWithArgs("./testdata/instances", "--no-colour")
+	assert.Contains(t, before, "via ")
+	assert.NotContains(t, before, `module.buckets["a"].aws_s3_bucket.this`)
+
+	after, _, exit := runWithArgs("./testdata/instances", "--no-colour", "--flatten-modules")
+	assert.NotContains(t, after, "via ")
+	assert.Contains(t, after, `as module.buckets["a"].aws_s3_bucket.this`)
+	assert.Equal(t, 1, exit)
+}
+
+func Test_Flag_ModulePrefix(t *testing.T) {
+	filesFor := func(args ...string) map[string]int {
+		out, _, exit := runWithArgs(append([]string{"./testdata/module-prefix", "-f", "json"}, args...)...)
+		assert.Equal(t, 1, exit)
+		files := make(map[string]int)
+		for _, result := range parseJSON(t, out) {
+			files[filepath.Base(filepath.Dir(result.Location.Filename))]++
+		}
+		return files
+	}
+
+	network := filesFor("--module-prefix", "module.network")
+	assert.Greater(t, network["network"], 0)
+	assert.Greater(t, network["subnets"], 0, "results from nested modules should be included")
+	assert.NotContains(t, network, "other", "module.network2 should not match the module.network prefix")
+	assert.NotContains(t, network, "module-prefix", "results from the root module should not be included")
+
+	nested := filesFor("--module-prefix", `module.network.module.subnets[1]`)
+	assert.Equal(t, network["subnets"]/2, nested["subnets"])
+	assert.Len(t, nested, 1)
+}
+
+func Test_Flag_PrintEvaluatedConfig(t *testing.T) {
+	type resource struct {
+		Address       string                 `json:"address"`
+		Mode          string                 `json:"mode"`
+		Type          string                 `json:"type"`
+		Name          string                 `json:"name"`
+		Index         interface{}            `json:"index"`
+		Values        map[string]interface{} `json:"values"`
+		UnknownValues map[string]interface{} `json:"unknown_values"`
+		Range         struct {
+			Filename  string `json:"filename"`
+			StartLine int    `json:"start_line"`
+		} `json:"range"`
+	}
+	type module struct {
+		Address      string     `json:"address"`
+		Resources    []resource `json:"resources"`
+		ChildModules []module   `json:"child_modules"`
+	}
+	evaluate := func(args ...string) module {
+		out, _, exit := runWithArgs(append([]string{"./testdata/evaluated", "--print-evaluated-config"}, args...)...)
+		require.Equal(t, 0, exit)
+		var config struct {
+			FormatVersion string `json:"format_version"`
+			PlannedValues struct {
+				RootModule module `json:"root_module"`
+			} `json:"planned_values"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(out), &config))
+		assert.Equal(t, "1.0", config.FormatVersion)
+		return config.PlannedValues.RootModule
+	}
+
+	root := evaluate()
+	require.Len(t, root.Resources, 3)
+	assert.Equal(t, "data", root.Resources[0].Mode)
+	web := root.Resources[1]
+	assert.Equal(t, "aws_security_group.web[0]", web.Address)
+	assert.Equal(t, "managed", web.Mode)
+	assert.Equal(t, "aws_security_group", web.Type)
+	assert.Equal(t, "web", web.Name)
+	assert.Equal(t, float64(0), web.Index)
+	assert.Equal(t, "app-prod-web-0", web.Values["name"])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"cidr_blocks": []interface{}{"10.0.0.0/8"},
+		"from_port":   float64(443),
+		"protocol":    "tcp",
+		"to_port":     float64(443),
+	}}, web.Values["ingress"], "nested blocks should be given as a list of objects")
+	assert.Equal(t, map[string]interface{}{"Owner": nil}, web.Values["tags"])
+	assert.Equal(t, map[string]interface{}{"tags": map[string]interface{}{"Owner": true}}, web.UnknownValues,
+		"unknown values should be marked")
+	assert.Equal(t, "main.tf", web.Range.Filename)
+	assert.Equal(t, 11, web.Range.StartLine)
+
+	require.Len(t, root.ChildModules, 2)
+	logs := root.ChildModules[0]
+	assert.Equal(t, `module.buckets["logs"]`, logs.Address)
+	require.Len(t, logs.Resources, 1)
+	assert.Equal(t, `module.buckets["logs"].aws_s3_bucket.this`, logs.Resources[0].Address)
+	assert.Equal(t, "app-prod-logs", logs.Resources[0].Values["bucket"])
+	assert.Equal(t, "modules/bucket/main.tf", logs.Resources[0].Range.Filename)
+
+	root = evaluate("--var-file", "./testdata/evaluated/dev.tfvars")
+	assert.Equal(t, "app-dev-web-0", root.Resources[1].Values["name"], "tfvars should be applied")
+}
+
+func Test_Flag_PrintEvaluatedConfigForEachRoot(t *testing.T) {
+	out, _, exit := runWithArgs("./testdata/evaluated-roots", "--print-evaluated-config")
+	require.Equal(t, 0, exit)
+
+	type config struct {
+		Root          string `json:"root"`
+		PlannedValues struct {
+			RootModule struct {
+				Resources []struct {
+					Values map[string]interface{} `json:"values"`
+					Range  struct {
+						Filename string `json:"filename"`
+					} `json:"range"`
+				} `json:"resources"`
+			} `json:"root_module"`
+		} `json:"planned_values"`
+	}
+	var found []string
+	decoder := json.NewDecoder(strings.NewReader(out))
+	for decoder.More() {
+		var document config
+		require.NoError(t, decoder.Decode(&document))
+		for _, resource := range document.PlannedValues.RootModule.Resources {
+			found = append(found, fmt.Sprintf("%s %s %s", document.Root, resource.Range.Filename, resource.Values["bucket"]))
+		}
+	}
+	assert.Equal(t, []string{
+		"prod prod/main.tf prod-assets",
+		"staging staging/main.tf staging-assets",
+	}, found, "each root module should be written as a separate document")
+}
+
+func Test_Flag_BlockTypes(t *testing.T) {
+	resources := func(args ...string) map[string]bool {
+		out, _, exit := runWithArgs(append([]string{"./testdata/block-types", "-f", "json"}, args...)...)
+		assert.Equal(t, 1, exit)
+		found := make(map[string]bool)
+		for _, result := range parseJSON(t, out) {
+			found[result.Resource] = true
+		}
+		return found
+	}
+
+	all := map[string]bool{"aws_s3_bucket.logs": true, "data.aws_iam_policy_document.admin": true}
+	assert.Equal(t, all, resources())
+	assert.Equal(t, all, resources("--block-types", "resource,data"))
+	assert.Equal(t, map[string]bool{"aws_s3_bucket.logs": true}, resources("--block-types", "resource,locals"),
+		"results caused by blocks of other types should not be shown")
+	assert.Equal(t, map[string]bool{"data.aws_iam_policy_document.admin": true}, resources("--block-types", "data"))
+}
+
+func Test_Flag_ResourceTypes(t *testing.T) {
+	services := func(args ...string) map[string]bool {
+		out, _, exit := runWithArgs(append([]string{"./testdata/resource-types", "-f", "json"}, args...)...)
+		assert.Equal(t, 1, exit)
+		found := make(map[string]bool)
+		for _, result := range parseJSON(t, out) {
+			found[result.RuleService] = true
+		}
+		return found
+	}
+
+	assert.Equal(t, map[string]bool{"s3": true, "vpc": true, "ebs": true}, services())
+	assert.Equal(t, map[string]bool{"s3": true, "vpc": true}, services("--resource-types", "aws_s3_bucket,aws_security_group"),
+		"only resources of the given types should produce results, including those within modules")
+	assert.Equal(t, map[string]bool{"ebs": true}, services("--resource-types", "aws_ebs_volume"))
+}
+
+func Test_Flag_ModuleOverride(t *testing.T) {
+	// results in modules loaded via modules.json have the declared source as a prefix of their filename
+	forks := func(args ...string) []string {
+		out, _, _ := runWithArgs(append([]string{"./testdata/module-override/project", "-f", "json", "--no-module-downloads"}, args...)...)
+		var found []string
+		for _, result := range parseJSON(t, out) {
+			if result.LongID != "aws-s3-no-public-access-with-acl" {
+				continue
+			}
+			_, file, _ := strings.Cut(filepath.ToSlash(result.Location.Filename), "/testdata/module-override/forks/")
+			found = append(found, file)
+		}
+		sort.Strings(found)
+		return found
+	}
+
+	assert.Empty(t, forks(), "the remote module should not be loaded")
+	assert.Equal(t, []string{"bucket/main.tf"}, forks(
+		"--module-override", "bucket=./testdata/module-override/forks/bucket",
+	))
+	assert.Equal(t, []string{"bucket/main.tf", "logs/main.tf"}, forks(
+		"--module-override", "bucket=./testdata/module-override/forks/bucket",
+		"--module-override", "module.bucket.module.logs=./testdata/module-override/forks/logs",
+	), "nested modules should be overridden by key or by address")
+
+	// the subdirectory of the declared source is appended to the directory found in modules.json
+	network := []string{"terraform-network/modules/vpc/main.tf"}
+	assert.Equal(t, network, forks(
+		"--module-override", "network=./testdata/module-override/forks/terraform-network/modules/vpc",
+	), "the module directory should be loaded")
+	assert.Equal(t, network, forks(
+		"--module-override", "network=./testdata/module-override/forks/terraform-network",
+	), "the subdirectory of a repository checkout should be loaded")
+
+	_, stderr, exit := runWithArgs("./testdata/module-override/project", "--no-module-downloads",
+		"--module-override", "network=./testdata/module-override/forks/bucket")
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, stderr, "selects the subdirectory 'modules/vpc'")
+
+	_, stderr, exit = runWithArgs("./testdata/module-override/project", "--module-override", "bucket")
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, stderr, "should be given as name=path")
+}
+
+func Test_Flag_ScanExamples(t *testing.T) {
+	publicACL := func(args ...string) []string {
+		out, _, exit := runWithArgs(append([]string{"./testdata/examples", "-f", "json"}, args...)...)
+		assert.Equal(t, 1, exit)
+		var descriptions []string
+		for _, result := range parseJSON(t, out) {
+			if result.LongID == "aws-s3-no-public-access-with-acl" {
+				descriptions = append(descriptions, result.Description)
+			}
+		}
+		return descriptions
+	}
+
+	assert.Len(t, publicACL(), 0, "the module defaults should not be public")
+
+	found := publicACL("--scan-examples")
+	require.Len(t, found, 1)
+	assert.True(t, strings.HasSuffix(found[0], "(example: public)"))
+}
+
+func Test_Flag_ListIgnores(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/suppressions", "--list-ignores")
+	assert.Equal(t, 0, exit)
+	assert.Equal(t, "", err)
+	assert.Equal(t, `main.tf:1 aws-s3-enable-versioning
+main.tf:4 aws-s3-no-public-access-with-acl expires:2030-01-01 workspace:prod
+modules/logs/main.tf:1 aws-s3-enable-bucket-logging[bucket=logs]
+`, out)
+
+	out, _, exit = runWithArgs("./testdata/suppressions", "--list-ignores", "-f", "json")
+	assert.Equal(t, 0, exit)
+	var report struct {
+		Ignores []struct {
+			Filename  string            `json:"filename"`
+			Line      int               `json:"line"`
+			RuleID    string            `json:"rule_id"`
+			Params    map[string]string `json:"params"`
+			Expiry    string            `json:"expiry"`
+			Workspace string            `json:"workspace"`
+		} `json:"ignores"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	require.Len(t, report.Ignores, 3)
+	assert.Equal(t, "2030-01-01T00:00:00Z", report.Ignores[1].Expiry)
+	assert.Equal(t, "prod", report.Ignores[1].Workspace)
+	assert.Equal(t, map[string]string{"bucket": "logs"}, report.Ignores[2].Params)
+}
+
+func Test_Flag_IgnoreExpiryWarning(t *testing.T) {
+	tmp, err := os.MkdirTemp(os.TempDir(), "tfsec")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	soon := time.Now().AddDate(0, 0, 3).Format("2006-01-02")
+	fresh := time.Now().AddDate(0, 0, 90).Format("2006-01-02")
+	source := fmt.Sprintf(`
+#tfsec:ignore:aws-s3-enable-versioning:exp:2020-01-01
+resource "aws_s3_bucket" "expired" {
+  bucket = "expired"
+}
+
+#tfsec:ignore:aws-s3-enable-versioning:exp:%s
+resource "aws_s3_bucket" "soon" {
+  bucket = "soon"
+}
+
+#tfsec:ignore:aws-s3-enable-versioning:exp:%s
+resource "aws_s3_bucket" "fresh" {
+  bucket = "fresh"
+}
+`, soon, fresh)
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "main.tf"), []byte(source), 0600))
+
+	_, stderr, _ := runWithArgs(tmp, "-f", "json")
+	assert.Equal(t, "", stderr)
+
+	out, stderr, exit := runWithArgs(tmp, "-f", "json", "--ignore-expiry-warning", "7")
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, stderr, fmt.Sprintf("WARNING: The ignore for aws-s3-enable-versioning at main.tf:7 expires on %s.", soon))
+	assert.NotContains(t, stderr, "main.tf:2 ")
+	assert.NotContains(t, stderr, "main.tf:12")
+
+	var versioning []string
+	var expired []int
+	for _, result := range parseJSON(t, out) {
+		switch result.LongID {
+		case "aws-s3-enable-versioning":
+			versioning = append(versioning, result.Resource)
+		case "general-terraform-expired-ignore":
+			expired = append(expired, result.Location.StartLine)
+			assert.Equal(t, "The ignore for aws-s3-enable-versioning expired on 2020-01-01 and no longer applies.", result.Description)
+		}
+	}
+	assert.Equal(t, []string{"aws_s3_bucket.expired"}, versioning)
+	assert.Equal(t, []int{2}, expired, "expired ignores should be reported as results")
+
+	out, _, _ = runWithArgs(tmp, "-f", "json", "--ignore-expiry-warning", "7", "--exclude", "general-terraform-expired-ignore")
+	assert.NotContains(t, out, "general-terraform-expired-ignore")
+}
+
+func Test_Flag_NoGitLFS(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	// createRepo commits a module with a public bucket to a new repository, and returns a configuration which
+	// calls it. Git LFS is assigned to binary files when lfs is set, although no LFS files are committed.
+	createRepo := func(t *testing.T, lfs bool) string {
+		repo := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(repo, "main.tf"), []byte(`
+resource "aws_s3_bucket" "this" {
+  acl = "public-read"
+}
+`), 0600))
+		if lfs {
+			require.NoError(t, os.WriteFile(filepath.Join(repo, ".gitattributes"), []byte("*.bin filter=lfs diff=lfs merge=lfs -text\n"), 0600))
+		}
+		for _, args := range [][]string{
+			{"init", "-q"},
+			{"add", "-A"},
+			{"-c", "user.name=tfsec", "-c", "user.email=tfsec@example.com", "commit", "-q", "-m", "module"},
+		} {
+			git := exec.Command("git", args...)
+			git.Dir = repo
+			output, err := git.CombinedOutput()
+			require.NoError(t, err, string(output))
+		}
+		config := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(config, "main.tf"), []byte(fmt.Sprintf(`
+module "bucket" {
+  source = "git::%s"
+}
+`, filepath.ToSlash(repo))), 0600))
+		return config
+	}
+
+	publicACL := func(out string) int {
+		var count int
+		for _, result := range parseJSON(t, out) {
+			if result.LongID == "aws-s3-no-public-access-with-acl" {
+				count++
+			}
+		}
+		return count
+	}
+
+	t.Run("without lfs", func(t *testing.T) {
+		out, stderr, _ := runWithArgs(createRepo(t, false), "-f", "json")
+		assert.Equal(t, 1, publicACL(out))
+		assert.NotContains(t, stderr, "Git LFS")
+	})
+
+	t.Run("lfs without git-lfs installed", func(t *testing.T) {
+		// link everything on the path except git-lfs, so that it cannot be found even if it is installed
+		bin := t.TempDir()
+		for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+			entries, _ := os.ReadDir(dir)
+			for _, entry := range entries {
+				if entry.Name() != "git-lfs" {
+					_ = os.Symlink(filepath.Join(dir, entry.Name()), filepath.Join(bin, entry.Name()))
+				}
+			}
+		}
+		t.Setenv("PATH", bin)
+
+		config := createRepo(t, true)
+		out, stderr, _ := runWithArgs(config, "-f", "json")
+		assert.Equal(t, 0, publicACL(out), "the module should not be loaded without its LFS files")
+		assert.Contains(t, stderr, "uses Git LFS and could not be loaded: git-lfs is not installed")
+
+		out, stderr, _ = runWithArgs(config, "-f", "json", "--no-git-lfs")
+		assert.Equal(t, 1, publicACL(out), "the module should be loaded without its LFS files when opted out")
+		assert.NotContains(t, stderr, "Git LFS")
+	})
+}
+
+func Test_Flag_ListUnusedModules(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/unused-modules", "--list-unused-modules")
+	assert.Equal(t, "", err)
+	assert.Equal(t, 0, exit)
+	assert.Equal(t, "legacy\nmodules/stale\n", out, "sources using locals and variable defaults should be followed")
+}
+
+func Test_Flag_ListProviderConstraints(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/provider-constraints", "--list-provider-constraints")
+	assert.Equal(t, "", err)
+	assert.Equal(t, 0, exit)
+	assert.Equal(t, `main.tf:3 aws source:hashicorp/aws version:~> 4.0
+main.tf:7 google source:hashicorp/google version:>= 4.50, < 5.0
+modules/dns/versions.tf:3 cloudflare version:~> 3.0
+modules/dns/versions.tf:4 aws source:hashicorp/aws unpinned
+modules/random/versions.tf.json:4 random source:hashicorp/random version:3.4.3
+`, out)
+
+	out, _, exit = runWithArgs("./testdata/provider-constraints", "--list-provider-constraints", "-f", "json")
+	assert.Equal(t, 0, exit)
+	var report struct {
+		Providers []struct {
+			Module  string `json:"module"`
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"providers"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	require.Len(t, report.Providers, 5)
+	assert.Equal(t, "modules/dns", report.Providers[3].Module)
+	assert.Equal(t, "aws", report.Providers[3].Name)
+	assert.Equal(t, "", report.Providers[3].Version)
+}
+
+func Test_Flag_WarnModuleShorthand(t *testing.T) {
+	_, stderr, _ := runWithArgs("./testdata/module-shorthand", "--no-module-downloads")
+	assert.NotContains(t, stderr, "shorthand", "the advisory should only be written with the flag")
+
+	_, stderr, _ = runWithArgs("./testdata/module-shorthand", "--no-module-downloads", "--warn-module-shorthand")
+	assert.Equal(t, `WARNING: main.tf:1-3 module 'network' uses the shorthand source 'github.com/example/terraform-network//modules/vpc?ref=v1.2.0'. Use an explicit source such as 'git::https://github.com/example/terraform-network.git//modules/vpc?ref=<tag>' with a pinned ref instead.
+WARNING: main.tf:9-11 module 'storage' uses the shorthand source 'bitbucket.org/example/terraform-storage'. Use an explicit source such as 'git::https://bitbucket.org/example/terraform-storage.git?ref=<tag>' with a pinned ref instead.
+`, stderr)
+}
+
+func Test_Flag_ModuleSourceErrors(t *testing.T) {
+	loadErrors := func(args ...string) []string {
+		out, _, _ := runWithArgs(append([]string{"./testdata/module-sources", "-f", "json"}, args...)...)
+		var found []string
+		for _, result := range parseJSON(t, out) {
+			if result.LongID == "general-terraform-module-load-error" {
+				found = append(found, result.Resource)
+			}
+		}
+		sort.Strings(found)
+		return found
+	}
+
+	assert.Empty(t, loadErrors(), "modules without a source should be left out by default")
+	assert.Empty(t, loadErrors("--module-source-errors", "skip"))
+	assert.Equal(t, []string{"module.nosource", "module.unset"}, loadErrors("--module-source-errors", "finding"),
+		"only modules without a source which can be resolved should be reported")
+	assert.Equal(t, []string{"module.missing", "module.nosource", "module.unset"}, loadErrors("--module-source-errors", "finding", "--hcl-errors-as-results"))
+
+	out, stderr, exit := runWithArgs("./testdata/module-sources", "--module-source-errors", "error")
+	assert.Equal(t, 1, exit)
+	assert.Equal(t, "", out)
+	assert.Contains(t, stderr, "the source of module 'module.unset' at main.tf:5-7, 'module.nosource' at main.tf:9-10 could not be resolved")
+
+	_, stderr, exit = runWithArgs("./testdata/module-sources", "--module-source-errors", "warning")
+	assert.Equal(t, 1, exit)
+	assert.Contains(t, stderr, "'warning' is not a valid way to handle module source errors")
+}
+
+func Test_Flag_StrictModuleWarnings(t *testing.T) {
+	_, stderr, exit := runWithArgs("./testdata/module-load-errors", "--soft-fail")
+	assert.Equal(t, 0, exit)
+	assert.NotContains(t, stderr, "could not be loaded", "module warnings should only be written with the flag")
+
+	out, stderr, exit := runWithArgs("./testdata/module-load-errors", "--soft-fail", "--strict-module-warnings", "-f", "json")
+	assert.Equal(t, 1, exit, "module warnings should fail the scan")
+	assert.Equal(t, `WARNING: main.tf:1-3 Module 'module.missing' could not be loaded from './modules/missing'.
+WARNING: main.tf:6-8 Module 'module.ignored' could not be loaded from './modules/removed'.
+The scan failed because of 2 module warning(s) with --strict-module-warnings
+`, stderr)
+	assert.NotEmpty(t, parseJSON(t, out), "the results should still be written")
+
+	_, stderr, exit = runWithArgs("./testdata/pass", "--strict-module-warnings")
+	assert.Equal(t, 0, exit)
+	assert.Equal(t, "", stderr)
+}
+
+func Test_Flag_ValidateModuleCalls(t *testing.T) {
+	found := func(args ...string) []string {
+		out, _, _ := runWithArgs(append([]string{"./testdata/module-calls", "-f", "json"}, args...)...)
+		var descriptions []string
+		for _, result := range parseJSON(t, out) {
+			if result.LongID == "general-terraform-module-call-arguments" {
+				descriptions = append(descriptions, fmt.Sprintf("%s:%d %s", filepath.Base(result.Location.Filename), result.Location.StartLine, result.Description))
+			}
+		}
+		return descriptions
+	}
+
+	assert.Empty(t, found(), "module calls should only be validated with the flag")
+	assert.Equal(t, []string{
+		"main.tf:4 Module 'module.logs' is called with the argument 'acl_name', which is not a variable of the module.",
+		"main.tf:1 Module 'module.logs' does not set the required variable 'region'.",
+	}, found("--validate-module-calls"))
+	assert.Empty(t, found("--validate-module-calls", "--exclude", "general-terraform-module-call-arguments"))
+}
+
+func Test_Flag_CheckCIDROverlaps(t *testing.T) {
+	out, _, exit := runWithArgs("./testdata/cidr-overlaps", "--check-cidr-overlaps", "-f", "json")
+	assert.Equal(t, 1, exit)
+	var overlapping []string
+	for _, result := range parseJSON(t, out) {
+		if result.LongID == "general-network-overlapping-cidr-blocks" {
+			overlapping = append(overlapping, result.Resource)
+			assert.Contains(t, result.Description, "10.0.1.128/25, which overlaps 10.0.1.0/24")
+		}
+	}
+	assert.Equal(t, []string{"aws_subnet.legacy"}, overlapping,
+		"only subnets in the same module should be compared, and those from cidrsubnets should not overlap")
+
+	out, _, _ = runWithArgs("./testdata/cidr-overlaps", "-f", "json")
+	assert.NotContains(t, out, "general-network-overlapping-cidr-blocks", "overlapping CIDR blocks should only be reported with the flag")
+}
+
+func Test_Flag_ListUnusedVariables(t *testing.T) {
+	out, err, exit := runWithArgs("./testdata/unused-variables", "--list-unused-variables")
+	assert.Equal(t, "", err)
+	assert.Equal(t, 0, exit)
+	assert.Equal(t, "main.tf:6 region\nmodules/bucket/outputs.tf.json:3 versioning\nmodules/bucket/variables.tf:8 acl\n", out, `references such as var["owner"] should count`)
+}
+
+func Test_Flag_ModuleChain(t *testing.T) {
+	out, _, exit := runWithArgs("./testdata/module-chain", "-f", "json", "--module-chain")
+	assert.Equal(t, 1, exit)
+	results := parseJSON(t, out)
+	require.Greater(t, len(results), 0)
+	for _, result := range results {
+		assert.True(t, strings.HasSuffix(result.Description, "(module chain: module.a → module.b → module.c → aws_s3_bucket.x)"), result.Description)
+	}
+
+	out, _, _ = runWithArgs("./testdata/module-chain", "-f", "json")
+	for _, result := range parseJSON(t, out) {
+		assert.NotContains(t, result.Description, "module chain")
+	}
+}
+
+func Test_Flag_AtlantisProjects(t *testing.T) {
+	publicACL := func(args ...string) []string {
+		out, _, exit := runWithArgs(append([]string{"./testdata/atlantis", "-f", "json"}, args...)...)
+		assert.Equal(t, 1, exit)
+		var found []string
+		for _, result := range parseJSON(t, out) {
+			if result.LongID == "aws-s3-no-public-access-with-acl" {
+				found = append(found, fmt.Sprintf("%s %s", result.Resource, result.Description))
+			}
+		}
+		sort.Strings(found)
+		return found
+	}
+
+	// without the flag the nested apps/web root is not found, as network and unlisted are found first
+	assert.Equal(t, []string{
+		"aws_s3_bucket.unlisted Bucket has a public ACL: 'public-read'.",
+	}, publicACL())
+
+	assert.Equal(t, []string{
+		"aws_s3_bucket.public[0] Bucket has a public ACL: 'public-read'. (project: network-prod)",
+		"aws_s3_bucket.web Bucket has a public ACL: 'public-read'.",
+	}, publicACL("--atlantis-projects"))
+}
+
+func Test_Flag_BaselineDir(t *testing.T) {
+	out, stderr, exit := runWithArgs("./testdata/baseline/after", "-f", "json", "--baseline-dir", "./testdata/baseline/before")
+	assert.Equal(t, 1, exit)
+	results := parseJSON(t, out)
+	require.Greater(t, len(results), 0)
+	for _, result := range results {
+		assert.Equal(t, "aws_s3_bucket.site", result.Resource, "only results introduced since the baseline should be reported")
+	}
+	assert.Regexp(t, `Compared with the baseline: \d+ new, \d+ unchanged and [1-9]\d* fixed result\(s\)`, stderr)
+
+	out, _, _ = runWithArgs("./testdata/baseline/after", "-f", "json", "--baseline-dir", "./testdata/baseline/before", "--include-ignored")
+	var unchanged bool
+	for _, result := range parseJSON(t, out) {
+		if result.Resource == "aws_s3_bucket.logs" && result.LongID == "aws-s3-no-public-access-with-acl" {
+			unchanged = true
+			assert.Equal(t, scan.StatusIgnored, result.Status)
+		}
+	}
+	assert.True(t, unchanged, "results also in the baseline should be ignored")
+
+	_, _, exit = runWithArgs("./testdata/baseline/before", "--baseline-dir", "./testdata/baseline/before")
+	assert.Equal(t, 0, exit, "a scan with no new results should pass")
+}
+
+func Test_Flag_BaselineDirWithMovedBlocks(t *testing.T) {
+	out, stderr, exit := runWithArgs("./testdata/baseline-moved/after", "-f", "json", "--baseline-dir", "./testdata/baseline-moved/before")
+	assert.Len(t, parseJSON(t, out), 0, "results for moved resources and modules should match the baseline")
+	assert.Regexp(t, `Compared with the baseline: 0 new, [1-9]\d* unchanged and 0 fixed result\(s\)`, stderr)
+	assert.Equal(t, 0, exit)
+}
+
+func Test_Flag_BaselineDirWithMovedBlocksBelowModulesDir(t *testing.T) {
+	// the directories above the scanned one should not affect which moved blocks are used
+	dir := filepath.Join(t.TempDir(), "modules", "app")
+	err := filepath.WalkDir("./testdata/baseline-moved", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel("./testdata/baseline-moved", path)
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return os.MkdirAll(filepath.Join(dir, rel), 0o755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, rel), data, 0o600)
+	})
+	require.NoError(t, err)
+
+	out, stderr, exit := runWithArgs(filepath.Join(dir, "after"), "-f", "json", "--baseline-dir", filepath.Join(dir, "before"))
+	assert.Len(t, parseJSON(t, out), 0, "results for moved resources and modules should match the baseline")
+	assert.Regexp(t, `Compared with the baseline: 0 new, [1-9]\d* unchanged and 0 fixed result\(s\)`, stderr)
+	assert.Equal(t, 0, exit)
+}
@@ -19,9 +19,14 @@ import (
 )
 
 func runWithArgs(args ...string) (stdout string, stderr string, exit int) {
+	return runWithStdin("", args...)
+}
+
+func runWithStdin(stdin string, args ...string) (stdout string, stderr string, exit int) {
 	sOut := bytes.NewBuffer([]byte{})
 	sErr := bytes.NewBuffer([]byte{})
 	rootCmd := cmd.Root()
+	rootCmd.SetIn(strings.NewReader(stdin))
 	rootCmd.SetOut(sOut)
 	rootCmd.SetErr(sErr)
 	rootCmd.SetArgs(args)
@@ -0,0 +1,5 @@
+resource "aws_s3_bucket" "public" {
+  count  = terraform.workspace == "prod" ? 1 : 0
+  bucket = "network-${terraform.workspace}"
+  acl    = "public-read"
+}
@@ -0,0 +1,4 @@
+resource "aws_s3_bucket" "unlisted" {
+  bucket = "unlisted"
+  acl    = "public-read"
+}
@@ -0,0 +1,18 @@
+resource "aws_s3_bucket" "access_logs" {
+  bucket = "logs"
+  acl    = "public-read"
+}
+
+moved {
+  from = aws_s3_bucket.logs
+  to   = aws_s3_bucket.access_logs
+}
+
+module "site" {
+  source = "./modules/bucket"
+}
+
+moved {
+  from = module.old
+  to   = module.site
+}
@@ -0,0 +1,10 @@
+# the logs bucket has moved down the file, but is otherwise unchanged
+resource "aws_s3_bucket" "site" {
+  bucket = "site"
+  acl    = "public-read"
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+  acl    = "public-read"
+}
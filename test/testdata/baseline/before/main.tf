@@ -0,0 +1,8 @@
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+  acl    = "public-read"
+}
+
+resource "aws_s3_bucket" "old" {
+  bucket = "old"
+}
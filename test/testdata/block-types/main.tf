@@ -0,0 +1,19 @@
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+
+data "aws_iam_policy_document" "admin" {
+  statement {
+    actions   = ["*"]
+    resources = ["*"]
+  }
+}
+
+resource "aws_iam_policy" "admin" {
+  name   = "admin"
+  policy = data.aws_iam_policy_document.admin.json
+}
+
+output "bucket" {
+  value = aws_s3_bucket.logs.bucket
+}
@@ -0,0 +1,22 @@
+locals {
+  subnets = cidrsubnets("10.0.0.0/16", 8, 8)
+}
+
+resource "aws_subnet" "public" {
+  vpc_id     = "vpc-12345678"
+  cidr_block = local.subnets[0]
+}
+
+resource "aws_subnet" "private" {
+  vpc_id     = "vpc-12345678"
+  cidr_block = local.subnets[1]
+}
+
+resource "aws_subnet" "legacy" {
+  vpc_id     = "vpc-12345678"
+  cidr_block = "10.0.1.128/25"
+}
+
+module "network" {
+  source = "./network"
+}
@@ -0,0 +1,4 @@
+resource "aws_subnet" "shared" {
+  vpc_id     = "vpc-87654321"
+  cidr_block = "10.0.0.0/24"
+}
@@ -0,0 +1,11 @@
+locals {
+  module_base = "./modules"
+}
+
+variable "module_name" {
+  default = "bucket"
+}
+
+module "bucket" {
+  source = "${local.module_base}/${var.module_name}"
+}
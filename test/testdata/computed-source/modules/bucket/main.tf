@@ -0,0 +1,4 @@
+resource "aws_s3_bucket" "this" {
+  bucket = "computed-source"
+  acl    = "public-read"
+}
@@ -0,0 +1,32 @@
+variable "environment" {
+  default = "prod"
+}
+
+locals {
+  prefix = "app-${var.environment}"
+}
+
+data "aws_caller_identity" "current" {}
+
+resource "aws_security_group" "web" {
+  count       = 2
+  name        = "${local.prefix}-web-${count.index}"
+  description = "web servers"
+
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["10.0.0.0/8"]
+  }
+
+  tags = {
+    Owner = data.aws_caller_identity.current.account_id
+  }
+}
+
+module "buckets" {
+  source   = "./modules/bucket"
+  for_each = toset(["logs", "site"])
+  name     = "${local.prefix}-${each.key}"
+}
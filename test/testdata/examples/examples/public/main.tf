@@ -0,0 +1,4 @@
+module "bucket" {
+  source = "../.."
+  acl    = "public-read"
+}
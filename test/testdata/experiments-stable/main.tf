@@ -0,0 +1,14 @@
+variable "bucket" {
+  type = object({
+    name = string
+    acl  = optional(string)
+  })
+  default = {
+    name = "assets"
+  }
+}
+
+resource "aws_s3_bucket" "assets" {
+  bucket = var.bucket.name
+  acl    = coalesce(var.bucket.acl, "private")
+}
@@ -0,0 +1,3 @@
+#tfsec:ignore:general-terraform-parse-error
+resource "aws_s3_bucket" "broken" {
+  bucket = "broken"
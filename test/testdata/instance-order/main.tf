@@ -0,0 +1,16 @@
+resource "aws_s3_bucket" "mapped" {
+  for_each = {
+    zulu    = "zulu"
+    alpha   = "alpha"
+    mike    = "mike"
+    bravo   = "bravo"
+    yankee  = "yankee"
+    charlie = "charlie"
+  }
+  bucket = each.value
+}
+
+resource "aws_s3_bucket" "counted" {
+  count  = 12
+  bucket = "counted-${count.index}"
+}
@@ -0,0 +1,5 @@
+module "buckets" {
+  for_each = toset(["a", "b", "c"])
+  source   = "./modules/bucket"
+  name     = each.key
+}
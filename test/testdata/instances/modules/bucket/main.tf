@@ -0,0 +1,4 @@
+variable "name" {}
+resource "aws_s3_bucket" "this" {
+  bucket = var.name
+}
@@ -0,0 +1,11 @@
+module "logs" {
+  source   = "./modules/bucket"
+  name     = "logs"
+  acl_name = "private"
+}
+
+module "assets" {
+  source = "./modules/bucket"
+  name   = "assets"
+  region = "eu-west-1"
+}
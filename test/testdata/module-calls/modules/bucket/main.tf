@@ -0,0 +1,16 @@
+variable "name" {
+  type = string
+}
+
+variable "region" {
+  type = string
+}
+
+variable "versioning" {
+  type    = bool
+  default = true
+}
+
+resource "aws_s3_bucket" "this" {
+  bucket = "${var.name}-${var.region}"
+}
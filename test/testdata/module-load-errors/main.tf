@@ -0,0 +1,12 @@
+module "missing" {
+  source = "./modules/missing"
+}
+
+#tfsec:ignore:general-terraform-module-load-error
+module "ignored" {
+  source = "./modules/removed"
+}
+
+module "bucket" {
+  source = "./modules/bucket"
+}
@@ -0,0 +1,8 @@
+resource "aws_s3_bucket" "site" {
+  bucket = "site"
+  acl    = "public-read"
+}
+
+module "logs" {
+  source = "git::https://github.com/example/terraform-logs.git?ref=v1.0.0"
+}
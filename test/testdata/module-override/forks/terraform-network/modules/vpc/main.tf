@@ -0,0 +1,4 @@
+resource "aws_s3_bucket" "flow_logs" {
+  bucket = "flow-logs"
+  acl    = "public-read"
+}
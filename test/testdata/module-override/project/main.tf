@@ -0,0 +1,7 @@
+module "bucket" {
+  source = "git::https://github.com/example/terraform-bucket.git?ref=v1.0.0"
+}
+
+module "network" {
+  source = "github.com/example/terraform-network//modules/vpc"
+}
@@ -0,0 +1,11 @@
+resource "aws_s3_bucket" "root" {
+
+}
+
+module "network" {
+  source = "./modules/network"
+}
+
+module "network2" {
+  source = "./modules/other"
+}
@@ -0,0 +1,8 @@
+resource "aws_s3_bucket" "network" {
+
+}
+
+module "subnets" {
+  count  = 2
+  source = "../subnets"
+}
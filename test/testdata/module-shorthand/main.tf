@@ -0,0 +1,11 @@
+module "network" {
+  source = "github.com/example/terraform-network//modules/vpc?ref=v1.2.0"
+}
+
+module "dns" {
+  source = "git::https://github.com/example/terraform-dns.git?ref=v1.0.0"
+}
+
+module "storage" {
+  source = "bitbucket.org/example/terraform-storage"
+}
@@ -0,0 +1,18 @@
+variable "bucket_module" {
+  type = string
+}
+
+module "unset" {
+  source = var.bucket_module
+}
+
+module "nosource" {
+}
+
+module "missing" {
+  source = "./modules/missing"
+}
+
+module "bucket" {
+  source = "./modules/bucket"
+}
@@ -0,0 +1,4 @@
+module "bucket" {
+  source  = "terraform-aws-modules/s3-bucket/aws"
+  version = "3.6.0"
+}
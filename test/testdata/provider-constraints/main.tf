@@ -0,0 +1,16 @@
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 4.0"
+    }
+    google = {
+      source  = "hashicorp/google"
+      version = ">= 4.50, < 5.0"
+    }
+  }
+}
+
+module "dns" {
+  source = "./modules/dns"
+}
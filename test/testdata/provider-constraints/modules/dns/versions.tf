@@ -0,0 +1,9 @@
+terraform {
+  required_providers {
+    cloudflare = "~> 3.0"
+    aws = {
+      source                = "hashicorp/aws"
+      configuration_aliases = [aws.us_east_1]
+    }
+  }
+}
@@ -0,0 +1,13 @@
+resource "aws_ebs_volume" "this" {
+  availability_zone = "eu-west-1a"
+  size              = 40
+}
+
+resource "aws_security_group" "this" {
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+}
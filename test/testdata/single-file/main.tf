@@ -0,0 +1,7 @@
+resource "aws_s3_bucket" "standalone" {
+
+}
+
+module "child" {
+  source = "./modules/child"
+}
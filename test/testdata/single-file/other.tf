@@ -0,0 +1,4 @@
+resource "aws_security_group_rule" "other" {
+  type        = "ingress"
+  cidr_blocks = ["0.0.0.0/0"]
+}
@@ -0,0 +1,9 @@
+# tfsec:ignore:aws-s3-enable-versioning
+resource "aws_s3_bucket" "data" {
+  bucket = "data"
+  acl    = "private" #tfsec:ignore:aws-s3-no-public-access-with-acl:exp:2030-01-01:ws:prod
+}
+
+module "logs" {
+  source = "./modules/logs"
+}
@@ -0,0 +1,4 @@
+// tfsec:ignore:aws-s3-enable-bucket-logging[bucket=logs]
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
@@ -0,0 +1,3 @@
+resource "backup_policy" "daily" {
+  expires = timeadd(timestamp(), "720h")
+}
@@ -0,0 +1,20 @@
+module "used" {
+  source = "./modules/used"
+}
+
+locals {
+  modules_root = "./modules"
+  network_root = "${local.modules_root}/network"
+}
+
+module "vpc" {
+  source = "${local.network_root}/vpc"
+}
+
+variable "modules_dir" {
+  default = "./modules"
+}
+
+module "dns" {
+  source = "${var.modules_dir}/dns"
+}
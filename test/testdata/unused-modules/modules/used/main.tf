@@ -0,0 +1,7 @@
+module "nested" {
+  source = "../nested"
+}
+
+resource "aws_s3_bucket" "used" {
+  bucket = "used"
+}
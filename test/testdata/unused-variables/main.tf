@@ -0,0 +1,25 @@
+variable "environment" {
+  default = "prod"
+}
+
+# declared but never referenced
+variable "region" {
+  default = "eu-west-1"
+}
+
+locals {
+  prefix = "app-${var.environment}"
+}
+
+module "bucket" {
+  source = "./modules/bucket"
+  name   = "${local.prefix}-logs"
+}
+
+variable "owner" {
+  default = "platform"
+}
+
+locals {
+  tags = { owner = var["owner"] }
+}
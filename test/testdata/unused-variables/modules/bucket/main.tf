@@ -0,0 +1,10 @@
+resource "aws_s3_bucket" "this" {
+  bucket = var.name
+
+  dynamic "grant" {
+    for_each = [for tag, value in var.tags : value]
+    content {
+      id = grant.value
+    }
+  }
+}
@@ -0,0 +1,15 @@
+variable "name" {}
+
+variable "tags" {
+  default = {}
+}
+
+# only referenced by its own validation
+variable "acl" {
+  default = "private"
+
+  validation {
+    condition     = contains(["private", "public-read"], var.acl)
+    error_message = "Unsupported ACL."
+  }
+}
@@ -0,0 +1,3 @@
+resource "aws_s3_bucket" "prod_only" {
+  count = terraform.workspace == "prod" ? 1 : 0
+}